@@ -15,10 +15,12 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/url"
 	"os"
 
 	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
@@ -33,11 +35,33 @@ var (
 
 	// Timeout is the timeout for the HTTP client used to make requests to the datasource (seconds).
 	Timeout = flag.Int("timeout", 30, "The timeout for the HTTP client used to make requests to the datasource (seconds)")
+
+	// ProxyURL is the URL of the HTTP(S) proxy used to make requests to the datasource.
+	ProxyURL = flag.String("proxy_url", "", "The URL of the HTTP(S) proxy used to make requests to the datasource")
+
+	// CABundle is the path of a PEM bundle of additional CAs trusted to verify the datasource certificates.
+	CABundle = flag.String("ca_bundle", "", "The path of a PEM bundle of additional CAs trusted to verify the datasource certificates")
+
+	// ClientCert is the path of the PEM client certificate presented to the datasource or proxy.
+	ClientCert = flag.String("client_cert", "", "The path of the PEM client certificate presented to the datasource or proxy")
+
+	// ClientKey is the path of the PEM private key of the client certificate.
+	ClientKey = flag.String("client_key", "", "The path of the PEM private key of the client certificate")
+
+	// InsecureSkipVerify disables the verification of the datasource certificates. Discouraged.
+	InsecureSkipVerify = flag.Bool("insecure_skip_verify", false, "Disable the verification of the datasource certificates (discouraged)")
 )
 
 func main() {
+	flag.Parse()
+
 	logger := log.New(os.Stdout, "adapter", log.Lmicroseconds|log.LUTC|log.Lshortfile)
 
+	clientOpts, err := transportOptions()
+	if err != nil {
+		logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *Port))
 	if err != nil {
 		logger.Fatalf("Failed to open server port: %v", err)
@@ -55,7 +79,7 @@ func main() {
 	// type configured on the Adapter object via the SGNL Config API.
 	//
 	// If you need to run multiple adapters on the same gRPC server, they can be registered here.
-	err = server.RegisterAdapter(adapterServer, "Test-1.0.0", adapter.NewAdapter(adapter.NewClient(*Timeout)))
+	err = server.RegisterAdapter(adapterServer, "Test-1.0.0", adapter.NewAdapter(adapter.NewClient(*Timeout, clientOpts...)))
 	if err != nil {
 		logger.Fatalf("Failed to register adapter: %v", err)
 	}
@@ -68,3 +92,42 @@ func main() {
 		logger.Fatalf("Failed to listen on server port: %v", err)
 	}
 }
+
+// transportOptions returns the options configuring the proxy and TLS settings
+// of the HTTP client from the flags.
+func transportOptions() ([]adapter.ClientOption, error) {
+	var opts []adapter.ClientOption
+
+	if *ProxyURL != "" {
+		proxyURL, err := url.Parse(*ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+
+		opts = append(opts, adapter.WithProxy(proxyURL))
+	}
+
+	if *CABundle != "" {
+		pool, err := adapter.LoadCABundle(*CABundle)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, adapter.WithRootCAs(pool))
+	}
+
+	if *ClientCert != "" || *ClientKey != "" {
+		certificate, err := tls.LoadX509KeyPair(*ClientCert, *ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		opts = append(opts, adapter.WithClientCertificates(certificate))
+	}
+
+	if *InsecureSkipVerify {
+		opts = append(opts, adapter.WithInsecureSkipVerify())
+	}
+
+	return opts, nil
+}
@@ -16,10 +16,13 @@ package adapter
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	framework "github.com/sgnl-ai/adapter-framework"
@@ -35,6 +38,12 @@ type Adapter struct {
 
 	// Client provides access to the datasource.
 	Client Client
+
+	// tokenProviders caches the token providers of the OAuth client
+	// credentials of the configs, keyed by credentials, so that access tokens
+	// are reused across GetPage calls until they expire.
+	tokenProviders   map[string]*ClientCredentialsProvider
+	tokenProvidersMu sync.Mutex
 }
 
 // NewAdapter instantiates a new Adapter.
@@ -71,11 +80,92 @@ func (a *Adapter) RequestPageFromDatasource(
 	}
 
 	req := &Request{
-		BaseURL:           request.Address,
-		HTTPAuthorization: request.Auth.HTTPAuthorization,
-		PageSize:          request.PageSize,
-		EntityExternalID:  request.Entity.ExternalId,
-		Cursor:            request.Cursor,
+		BaseURL:          request.Address,
+		PageSize:         request.PageSize,
+		EntityExternalID: request.Entity.ExternalId,
+		Cursor:           request.Cursor,
+	}
+
+	// The auth may be omitted if OAuth client credentials are configured.
+	if request.Auth != nil {
+		req.HTTPAuthorization = request.Auth.HTTPAuthorization
+	}
+
+	// Only the attributes requested by the framework are returned.
+	for _, attribute := range request.Entity.Attributes {
+		req.Attributes = append(req.Attributes, attribute.ExternalId)
+	}
+
+	if request.Config != nil {
+		req.Query = request.Config.Query
+		req.ParentID = request.Config.ParentID
+		req.APIVersion = request.Config.APIVersion
+		req.AttemptTimeout = time.Duration(request.Config.RequestTimeoutSeconds) * time.Second
+		req.AuthType = request.Config.AuthType
+		req.TokenProvider = a.tokenProvider(request.Config)
+		req.ResponseMapping = request.Config.responseMapping(req.EntityExternalID)
+
+		for _, region := range request.Config.Regions {
+			req.Regions = append(req.Regions, Region{Name: region.Name, BaseURL: region.BaseURL})
+		}
+		req.NormalizeDatetimes = request.Config.NormalizeDatetimes
+		req.RequestsPerMinute = request.Config.RequestsPerMinute
+
+		// Entities that don't support incremental syncs are fully synced.
+		if ValidEntityExternalIDs[req.EntityExternalID].highWaterMarkAttr != "" {
+			since, sinceErr := time.Parse(time.RFC3339, request.Config.Since)
+			until, untilErr := time.Parse(time.RFC3339, request.Config.Until)
+
+			if sinceErr == nil || untilErr == nil {
+				req.Options = &PageOptions{Since: since, Until: until}
+			}
+		}
+
+		if req.EntityExternalID == Incidents {
+			filters := map[string][]string{
+				"statuses[]":    request.Config.Statuses,
+				"urgencies[]":   request.Config.Urgencies,
+				"service_ids[]": request.Config.ServiceIDs,
+			}
+
+			// Empty filters are omitted from the query.
+			if req.Options == nil {
+				req.Options = &PageOptions{}
+			}
+
+			req.Options.Filters = filters
+		}
+
+		if len(request.Config.Includes) > 0 {
+			if req.Options == nil {
+				req.Options = &PageOptions{}
+			}
+
+			req.Options.Includes = request.Config.Includes
+		}
+
+		if request.Config.MaxRetries > 0 {
+			req.RetryPolicy = &RetryPolicy{
+				MaxRetries:     request.Config.MaxRetries,
+				InitialBackoff: time.Duration(request.Config.RetryBackoffMilliseconds) * time.Millisecond,
+			}
+		}
+	}
+
+	// The high-water mark of the previous pages is carried by the cursor of
+	// entities that support incremental syncs.
+	incremental := ValidEntityExternalIDs[req.EntityExternalID].highWaterMarkAttr != ""
+	highWaterMark := ""
+
+	if incremental && req.Cursor != "" {
+		cursor, cursorErr := parseCursor(req.Cursor)
+		if cursorErr != nil {
+			return framework.NewGetPageResponseError(cursorErr)
+		}
+
+		highWaterMark = cursor.HighWaterMark
+		cursor.HighWaterMark = ""
+		req.Cursor = encodeCursor(cursor)
 	}
 
 	resp, err := a.Client.GetPage(ctx, req)
@@ -85,10 +175,18 @@ func (a *Adapter) RequestPageFromDatasource(
 
 	// An adapter error message is generated if the response status code is not
 	// successful (i.e. if not statusCode >= 200 && statusCode < 300).
-	if adapterErr := web.HTTPError(resp.StatusCode, resp.RetryAfterHeader); adapterErr != nil {
+	if adapterErr := responseError(resp); adapterErr != nil {
 		return framework.NewGetPageResponseError(adapterErr)
 	}
 
+	if incremental {
+		highWaterMark = latestDatetime(highWaterMark, resp.HighWaterMark)
+
+		if err := withHighWaterMark(resp, highWaterMark); err != nil {
+			return framework.NewGetPageResponseError(err)
+		}
+	}
+
 	// The raw JSON objects from the response must be parsed and converted into framework.Objects.
 	// Nested attributes are flattened and delimited by the delimiter specified.
 	// DateTime values are parsed using the specified DateTimeFormatWithTimeZone.
@@ -137,3 +235,76 @@ func (a *Adapter) RequestPageFromDatasource(
 
 	return framework.NewGetPageResponseSuccess(page)
 }
+
+// withHighWaterMark adds the high-water mark to the last object of the
+// response, and to its next cursor so that the next page carries it on.
+func withHighWaterMark(resp *Response, highWaterMark string) *framework.Error {
+	if highWaterMark == "" {
+		return nil
+	}
+
+	if len(resp.Objects) > 0 {
+		resp.Objects[len(resp.Objects)-1][HighWaterMarkAttribute] = highWaterMark
+	}
+
+	if resp.NextCursor == "" {
+		return nil
+	}
+
+	cursor, err := parseCursor(resp.NextCursor)
+	if err != nil {
+		return err
+	}
+
+	cursor.HighWaterMark = highWaterMark
+	resp.NextCursor = encodeCursor(cursor)
+
+	return nil
+}
+
+// tokenProvider returns the cached token provider of the OAuth client
+// credentials of the config, creating it on first use.
+// Returns nil if the config has no client credentials.
+func (a *Adapter) tokenProvider(config *Config) TokenProvider {
+	if !config.hasClientCredentials() {
+		return nil
+	}
+
+	// The key doesn't contain the secret itself.
+	hash := sha256.New()
+
+	for _, part := range append([]string{config.OAuthTokenURL, config.OAuthClientID, config.OAuthClientSecret}, config.OAuthScopes...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	key := hex.EncodeToString(hash.Sum(nil))
+
+	a.tokenProvidersMu.Lock()
+	defer a.tokenProvidersMu.Unlock()
+
+	if provider, found := a.tokenProviders[key]; found {
+		return provider
+	}
+
+	provider := &ClientCredentialsProvider{
+		TokenURL:     config.OAuthTokenURL,
+		ClientID:     config.OAuthClientID,
+		ClientSecret: config.OAuthClientSecret,
+		Scopes:       config.OAuthScopes,
+	}
+
+	// Tokens are requested through the same transport as the datasource, e.g.
+	// with the same proxy and root CAs.
+	if datasource, ok := a.Client.(*Datasource); ok {
+		provider.Client = datasource.Client
+	}
+
+	if a.tokenProviders == nil {
+		a.tokenProviders = make(map[string]*ClientCredentialsProvider)
+	}
+
+	a.tokenProviders[key] = provider
+
+	return provider
+}
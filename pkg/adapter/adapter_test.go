@@ -0,0 +1,177 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestAdapterGetPageOncalls(t *testing.T) {
+	server, client := newTLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oncalls" {
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+
+		w.Write([]byte(`{"oncalls":[{"escalation_level":1,"start":null,"end":null}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	adapter := NewAdapter(client)
+
+	// On-call entries have no unique ID attribute.
+	response := adapter.GetPage(context.Background(), &framework.Request[Config]{
+		Address: server.URL,
+		Auth: &framework.DatasourceAuthCredentials{
+			HTTPAuthorization: "Token token=test",
+		},
+		Entity: framework.EntityConfig{
+			ExternalId: Oncalls,
+			Attributes: []*framework.AttributeConfig{
+				{ExternalId: "escalation_level", Type: framework.AttributeTypeInt64},
+			},
+		},
+		PageSize: 100,
+	})
+
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %v", response.Error)
+	}
+
+	AssertDeepEqual(t, &framework.Page{
+		Objects: []framework.Object{{"escalation_level": int64(1)}},
+	}, response.Success)
+}
+
+func TestAdapterGetPageMissingUniqueID(t *testing.T) {
+	adapter := NewAdapter(NewClient(5))
+
+	response := adapter.GetPage(context.Background(), &framework.Request[Config]{
+		Address: "https://api.pagerduty.com",
+		Auth: &framework.DatasourceAuthCredentials{
+			HTTPAuthorization: "Token token=test",
+		},
+		Entity: framework.EntityConfig{
+			ExternalId: Users,
+			Attributes: []*framework.AttributeConfig{
+				{ExternalId: "name", Type: framework.AttributeTypeString},
+			},
+		},
+		PageSize: 100,
+	})
+
+	AssertDeepEqual(t, &framework.Error{
+		Message: "Requested entity attributes are missing unique ID attribute.",
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+	}, response.Error)
+}
+
+func TestAdapterGetPageUnauthorized(t *testing.T) {
+	server, client := newTLSTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"Authentication failed","code":2006}}`))
+	})
+
+	adapter := NewAdapter(client)
+
+	// The error description of the datasource is reported.
+	response := adapter.GetPage(context.Background(), &framework.Request[Config]{
+		Address: server.URL,
+		Auth: &framework.DatasourceAuthCredentials{
+			HTTPAuthorization: "Token token=invalid",
+		},
+		Entity: framework.EntityConfig{
+			ExternalId: Users,
+			Attributes: []*framework.AttributeConfig{
+				{ExternalId: "id", Type: framework.AttributeTypeString},
+			},
+		},
+		PageSize: 100,
+	})
+
+	AssertDeepEqual(t, &framework.Error{
+		Message: "Failed to authenticate with datasource. Check datasource configuration details and try again. " +
+			"Datasource error 2006: Authentication failed.",
+		Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+	}, response.Error)
+}
+
+func TestAdapterGetPageHighWaterMark(t *testing.T) {
+	server, client := newTLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if since := r.URL.Query().Get("since"); since != "2024-01-01T00:00:00Z" {
+			t.Errorf("Expected the since of the config, got %q", since)
+		}
+
+		if r.URL.Query().Get("offset") == "0" {
+			w.Write([]byte(`{"incidents":[{"id":"Q1","created_at":"2024-01-03T00:00:00Z"},` +
+				`{"id":"Q2","created_at":"2024-01-02T00:00:00Z"}],"more":true,"limit":2,"offset":0}`))
+
+			return
+		}
+
+		w.Write([]byte(`{"incidents":[{"id":"Q3","created_at":"2024-01-02T12:00:00Z"}],"more":false,"limit":2,"offset":2}`))
+	})
+
+	adapter := NewAdapter(client)
+
+	request := &framework.Request[Config]{
+		Address: server.URL,
+		Auth: &framework.DatasourceAuthCredentials{
+			HTTPAuthorization: "Token token=test",
+		},
+		Config: &Config{Since: "2024-01-01T00:00:00Z"},
+		Entity: framework.EntityConfig{
+			ExternalId: Incidents,
+			Attributes: []*framework.AttributeConfig{
+				{ExternalId: "id", Type: framework.AttributeTypeString},
+				{ExternalId: HighWaterMarkAttribute, Type: framework.AttributeTypeString},
+			},
+		},
+		PageSize: 2,
+	}
+
+	response := adapter.GetPage(context.Background(), request)
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %v", response.Error)
+	}
+
+	// The high-water mark of the page is added to its last object, and
+	// carried by the cursor.
+	AssertDeepEqual(t, &framework.Page{
+		Objects: []framework.Object{
+			{"id": "Q1"},
+			{"id": "Q2", HighWaterMarkAttribute: "2024-01-03T00:00:00Z"},
+		},
+		NextCursor: encodeCursor(&pageCursor{Offset: 2, HighWaterMark: "2024-01-03T00:00:00Z"}),
+	}, response.Success)
+
+	request.Cursor = response.Success.NextCursor
+
+	response = adapter.GetPage(context.Background(), request)
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %v", response.Error)
+	}
+
+	// The last object of the sync has the latest high-water mark of all the
+	// pages.
+	AssertDeepEqual(t, &framework.Page{
+		Objects: []framework.Object{
+			{"id": "Q3", HighWaterMarkAttribute: "2024-01-03T00:00:00Z"},
+		},
+	}, response.Success)
+}
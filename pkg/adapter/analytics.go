@@ -0,0 +1,200 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+const (
+	// AnalyticsRawIncidents is the path of the analytics endpoint returning
+	// per-incident metric rows.
+	AnalyticsRawIncidents string = "analytics/raw/incidents"
+
+	// AnalyticsResponders is the path of the analytics endpoint returning
+	// per-responder aggregate metric rows.
+	AnalyticsResponders string = "analytics/metrics/responders/all"
+
+	// analyticsProcessingRetries is the number of times an empty page reported
+	// as having more data is requested again while the analytics API is still
+	// processing the data.
+	analyticsProcessingRetries = 3
+
+	// analyticsProcessingDelay is the delay before requesting again a page
+	// which is still being processed.
+	analyticsProcessingDelay = 2 * time.Second
+)
+
+// analyticsRequestBody is the body of a POST request to an analytics endpoint.
+type analyticsRequestBody struct {
+	Filters       map[string]any `json:"filters,omitempty"`
+	Limit         int64          `json:"limit,omitempty"`
+	StartingAfter string         `json:"starting_after,omitempty"`
+}
+
+// analyticsResponseBody is the body of a response from an analytics endpoint.
+type analyticsResponseBody struct {
+	Data []map[string]any `json:"data"`
+	More bool             `json:"more"`
+
+	// Last is the cursor of the last row in the page, used as the
+	// `starting_after` cursor of the next page.
+	Last string `json:"last"`
+}
+
+// GetAnalyticsRawIncidents returns a page of raw incident rows from the
+// analytics API, scoped by the given filters (e.g. `created_at_start`,
+// `team_ids`).
+// The request's Cursor and PageSize are used for cursor paging.
+//
+// The analytics API may return an empty page with `more` set while it is
+// still processing data. Such pages are requested again a few times, after
+// which an empty page is returned with NextCursor unchanged so that the caller
+// can resume from the same position later.
+func (d *Datasource) GetAnalyticsRawIncidents(
+	ctx context.Context, request *Request, filters map[string]any,
+) (*Response, *framework.Error) {
+	return d.getAnalyticsPage(ctx, request, AnalyticsRawIncidents, filters)
+}
+
+// ResponderAnalyticsFilters scopes the responders aggregated by
+// GetAnalyticsResponders.
+type ResponderAnalyticsFilters struct {
+	// Start and End bound the time range of the incidents the metrics are
+	// aggregated over.
+	Start time.Time
+	End   time.Time
+
+	// TeamIDs and ServiceIDs restrict the metrics to the incidents of the
+	// given teams and services.
+	// Optional.
+	TeamIDs    []string
+	ServiceIDs []string
+}
+
+// filters returns the filters of the analytics request body.
+func (f *ResponderAnalyticsFilters) filters() map[string]any {
+	filters := map[string]any{
+		"date_range_start": f.Start.UTC().Format(time.RFC3339),
+		"date_range_end":   f.End.UTC().Format(time.RFC3339),
+	}
+
+	if len(f.TeamIDs) > 0 {
+		filters["team_ids"] = f.TeamIDs
+	}
+
+	if len(f.ServiceIDs) > 0 {
+		filters["service_ids"] = f.ServiceIDs
+	}
+
+	return filters
+}
+
+// GetAnalyticsResponders returns a page of aggregate metric rows from the
+// analytics API, one per responder (e.g. the number of incidents they were
+// engaged in and their mean time to acknowledge), scoped by the given filters.
+// The request's Cursor and PageSize are used for cursor paging, and pages
+// still being processed are handled as in GetAnalyticsRawIncidents.
+func (d *Datasource) GetAnalyticsResponders(
+	ctx context.Context, request *Request, filters ResponderAnalyticsFilters,
+) (*Response, *framework.Error) {
+	if filters.Start.IsZero() || filters.End.IsZero() || !filters.Start.Before(filters.End) {
+		return nil, &framework.Error{
+			Message: "Responder analytics time range is invalid. Set a start before the end.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	return d.getAnalyticsPage(ctx, request, AnalyticsResponders, filters.filters())
+}
+
+func (d *Datasource) getAnalyticsPage(
+	ctx context.Context, request *Request, path string, filters map[string]any,
+) (*Response, *framework.Error) {
+	if requestErr := validateRequest(request); requestErr != nil {
+		return nil, requestErr
+	}
+
+	payload, err := json.Marshal(&analyticsRequestBody{
+		Filters:       filters,
+		Limit:         request.PageSize,
+		StartingAfter: request.Cursor,
+	})
+	if err != nil {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Failed to marshal analytics request filters: %v.", err),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	// Analytics endpoints are not entities, and take their filters in the body.
+	requestURL, urlErr := pageURL(Entity{path: path}, request, nil)
+	if urlErr != nil {
+		return nil, urlErr
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, body, doErr := d.do(ctx, request, http.MethodPost, requestURL, payload)
+		if doErr != nil {
+			return nil, doErr
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return response, nil
+		}
+
+		var data analyticsResponseBody
+
+		if unmarshalErr := json.Unmarshal(body, &data); unmarshalErr != nil {
+			return nil, &framework.Error{
+				Message: fmt.Sprintf("Failed to unmarshal the datasource response: %v.", unmarshalErr),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+			}
+		}
+
+		processing := len(data.Data) == 0 && data.More
+
+		if !processing {
+			response.Objects = data.Data
+
+			if data.More {
+				response.NextCursor = data.Last
+			}
+
+			return response, nil
+		}
+
+		if attempt == analyticsProcessingRetries {
+			response.Objects = []map[string]any{}
+			response.NextCursor = request.Cursor
+
+			return response, nil
+		}
+
+		if waitErr := wait(ctx, analyticsProcessingDelay); waitErr != nil {
+			return nil, &framework.Error{
+				Message: "Analytics data was still being processed when the request was canceled.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestGetAnalyticsRawIncidents(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		AssertDeepEqual(t, http.MethodPost, r.Method)
+		AssertDeepEqual(t, "/"+AnalyticsRawIncidents, r.URL.Path)
+		AssertDeepEqual(t, "us", r.URL.Query().Get("region"))
+
+		body, _ := io.ReadAll(r.Body)
+
+		AssertDeepEqual(t, `{"filters":{"team_ids":["T1"]},"limit":10,"starting_after":"C1"}`, string(body))
+
+		w.Write([]byte(`{"data":[{"incident_id":"I1"}],"more":true,"last":"C2"}`))
+	})
+
+	// Query parameters of the base URL are preserved.
+	request := newTestRequest(server, "")
+	request.BaseURL = server.URL + "/?region=us"
+	request.PageSize = 10
+	request.Cursor = "C1"
+
+	response, err := NewClient(5).(*Datasource).GetAnalyticsRawIncidents(context.Background(), request, map[string]any{"team_ids": []string{"T1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"incident_id": "I1"}}, response.Objects)
+	AssertDeepEqual(t, "C2", response.NextCursor)
+}
+
+func TestGetAnalyticsRawIncidentsInvalidRequest(t *testing.T) {
+	request := &Request{
+		BaseURL:           "https://api.pagerduty.com",
+		HTTPAuthorization: "Token token=test",
+	}
+
+	_, err := NewClient(5).(*Datasource).GetAnalyticsRawIncidents(context.Background(), request, nil)
+	if err == nil {
+		t.Fatal("Expected an error for a request without a page size")
+	}
+
+	AssertDeepEqual(t, api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG, err.Code)
+}
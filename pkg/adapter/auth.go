@@ -0,0 +1,186 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+const (
+	// AuthTypeToken sends Request.HTTPAuthorization as is, e.g. a PagerDuty
+	// API key in the "Token token=..." scheme.
+	AuthTypeToken = "token"
+
+	// AuthTypeOAuth sends Request.HTTPAuthorization as an OAuth access token
+	// in the Bearer scheme.
+	AuthTypeOAuth = "oauth"
+
+	// tokenPrefix is the prefix of the Authorization header of PagerDuty API
+	// keys.
+	tokenPrefix = "Token token="
+
+	// bearerPrefix is the prefix of the Authorization header of OAuth access
+	// tokens.
+	bearerPrefix = "Bearer "
+)
+
+// TokenProvider provides expiring credentials to authenticate with the
+// datasource, e.g. OAuth access tokens.
+type TokenProvider interface {
+	// Token returns the current value of the Authorization header, prefixed
+	// with the scheme, e.g. "Bearer ".
+	Token(ctx context.Context) (string, error)
+
+	// Refresh invalidates the current token and returns a new value of the
+	// Authorization header.
+	Refresh(ctx context.Context) (string, error)
+}
+
+// WithTokenProvider sets the TokenProvider used to authenticate requests.
+func WithTokenProvider(provider TokenProvider) ClientOption {
+	return func(d *Datasource) {
+		d.TokenProvider = provider
+	}
+}
+
+// send authenticates and sends the HTTP request to the datasource.
+//
+// If a TokenProvider is configured, for the request or the Datasource, and the
+// datasource responds with a 401, the token is refreshed and the request is
+// retried exactly once. A 401 is otherwise returned as is, so that its error
+// description is reported like that of other unsuccessful responses.
+func (d *Datasource) send(req *http.Request, request *Request) (*http.Response, *framework.Error) {
+	authorization, authErr := requestAuthorization(request)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	provider := d.tokenProvider(request)
+
+	if provider != nil {
+		token, err := provider.Token(req.Context())
+		if err != nil {
+			return nil, tokenError(err)
+		}
+
+		authorization = token
+	}
+
+	// An empty Authorization header would only be rejected with a 401.
+	if authorization == "" {
+		return nil, &framework.Error{
+			Message: "Datasource auth is missing. Set the request HTTPAuthorization or configure a token provider.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	req.Header.Set("Authorization", authorization)
+
+	client := d.httpClient()
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, sendError(err)
+	}
+
+	if res.StatusCode != http.StatusUnauthorized || provider == nil {
+		return res, nil
+	}
+
+	res.Body.Close()
+
+	token, refreshErr := d.refreshToken(req.Context(), provider, authorization)
+	if refreshErr != nil {
+		return nil, tokenError(refreshErr)
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set("Authorization", token)
+
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, &framework.Error{
+				Message: "Failed to create HTTP request to datasource.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+			}
+		}
+	}
+
+	res, err = client.Do(retry)
+	if err != nil {
+		return nil, sendError(err)
+	}
+
+	return res, nil
+}
+
+// refreshToken refreshes the token that was rejected by the datasource.
+// Concurrent callers rejected with the same token share a single refresh: once
+// the lock is acquired, a token that differs from the stale one means it was
+// already refreshed by another caller.
+func (d *Datasource) refreshToken(ctx context.Context, provider TokenProvider, staleToken string) (string, error) {
+	d.tokenMu.Lock()
+	defer d.tokenMu.Unlock()
+
+	if current, err := provider.Token(ctx); err == nil && current != staleToken {
+		return current, nil
+	}
+
+	return provider.Refresh(ctx)
+}
+
+// tokenProvider returns the TokenProvider of the request, if any, or else the
+// Datasource's.
+func (d *Datasource) tokenProvider(request *Request) TokenProvider {
+	if request.TokenProvider != nil {
+		return request.TokenProvider
+	}
+
+	return d.TokenProvider
+}
+
+// requestAuthorization returns the Authorization header value for the request's
+// HTTPAuthorization, formatted according to its AuthType.
+func requestAuthorization(request *Request) (string, *framework.Error) {
+	switch request.AuthType {
+	case "", AuthTypeToken:
+		return request.HTTPAuthorization, nil
+	case AuthTypeOAuth:
+		if request.HTTPAuthorization == "" {
+			return "", nil
+		}
+
+		// The token may already be prefixed, e.g. if copied from another client.
+		return bearerPrefix + strings.TrimPrefix(request.HTTPAuthorization, bearerPrefix), nil
+	default:
+		return "", &framework.Error{
+			Message: fmt.Sprintf("Datasource auth type %q is not supported. Use %q or %q.", request.AuthType, AuthTypeToken, AuthTypeOAuth),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+}
+
+func tokenError(err error) *framework.Error {
+	return &framework.Error{
+		Message: fmt.Sprintf("Failed to obtain datasource access token: %v.", err),
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_AUTHENTICATION_FAILED,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetPageAuthorizationHeader(t *testing.T) {
+	tests := map[string]struct {
+		authorization string
+		authType      string
+		wantHeader    string
+	}{
+		"default": {
+			authorization: "Token token=abc123",
+			wantHeader:    "Token token=abc123",
+		},
+		"token": {
+			authorization: "Token token=abc123",
+			authType:      AuthTypeToken,
+			wantHeader:    "Token token=abc123",
+		},
+		"oauth": {
+			authorization: "abc123",
+			authType:      AuthTypeOAuth,
+			wantHeader:    "Bearer abc123",
+		},
+		"oauth_prefixed": {
+			authorization: "Bearer abc123",
+			authType:      AuthTypeOAuth,
+			wantHeader:    "Bearer abc123",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				AssertDeepEqual(t, tt.wantHeader, r.Header.Get("Authorization"))
+				AssertDeepEqual(t, "application/vnd.pagerduty+json;version=2", r.Header.Get("Accept"))
+
+				w.Write([]byte(`{"users":[],"more":false,"limit":100,"offset":0}`))
+			})
+
+			request := newTestRequest(server, Users)
+			request.HTTPAuthorization = tt.authorization
+			request.AuthType = tt.authType
+
+			if _, err := NewClient(5).GetPage(context.Background(), request); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestAuthorizationUnsupportedType(t *testing.T) {
+	_, err := requestAuthorization(&Request{HTTPAuthorization: "abc123", AuthType: "basic"})
+	if err == nil {
+		t.Fatal("Expected an error for an unsupported auth type")
+	}
+}
@@ -16,6 +16,7 @@ package adapter
 
 import (
 	"context"
+	"time"
 
 	framework "github.com/sgnl-ai/adapter-framework"
 )
@@ -27,6 +28,8 @@ type Client interface {
 	// requested entity.
 	// Returns a (possibly empty) list of JSON objects, each object being
 	// unmarshaled into a map by Golang's JSON unmarshaler.
+	// Errors caused by the HTTP status code of the datasource are returned
+	// together with the response, see HTTPStatus.
 	GetPage(ctx context.Context, request *Request) (*Response, *framework.Error)
 }
 
@@ -44,9 +47,31 @@ type Request struct {
 	// Password is the password to use to authenticate with the datasource.
 	//Password string
 
+	// Regions are the service regions to page through in order, each with its
+	// own base URL, replacing BaseURL, e.g. to sync the accounts of both the
+	// US and EU regions with the same token.
+	// Optional. If empty, only BaseURL is requested.
+	Regions []Region
+
 	// HTTPAuthorization is the token to use to authenticate with the datasource.
 	HTTPAuthorization string
 
+	// AuthType is how HTTPAuthorization is sent in the Authorization header,
+	// either AuthTypeToken or AuthTypeOAuth.
+	// Optional. Defaults to AuthTypeToken.
+	AuthType string
+
+	// TokenProvider provides the Authorization header value of the request,
+	// overriding HTTPAuthorization and the Datasource's TokenProvider, e.g.
+	// for OAuth client credentials configured per request.
+	// Optional.
+	TokenProvider TokenProvider `json:"-"`
+
+	// APIVersion is the PagerDuty REST API version to request, e.g. "2".
+	// Overridden by the entity's API version, if any.
+	// Optional. Defaults to "2".
+	APIVersion string
+
 	// PageSize is the maximum number of objects to return from the entity.
 	PageSize int64
 
@@ -58,6 +83,181 @@ type Request struct {
 	// the last request for the entity.
 	// Optional. If not set, return the first page for this entity.
 	Cursor string
+
+	// ParentID is the ID of the parent object of a parent-scoped entity, e.g.
+	// the incident ID for incident status updates.
+	// Optional for parent-scoped entities, ignored otherwise. If not set, the
+	// pages contain the children of all the parents in turn, for entities whose
+	// parent entity can be listed (e.g. the members of all teams).
+	ParentID string
+
+	// Query filters objects by a name or label substring.
+	// Only sent for entities that support it (e.g. tags).
+	// Optional. If not set, objects are not filtered.
+	Query string
+
+	// QueryParams is the set of additional query parameters to send, keyed by
+	// parameter name, e.g. `since`, `until` or `statuses[]` filters. Overrides
+	// the entity's default query parameters. Empty values are omitted, and
+	// parameters are encoded sorted by name.
+	// Optional.
+	QueryParams map[string][]string
+
+	// Options contains the includes, filters, sort order and time window to
+	// apply when requesting the page.
+	// Optional.
+	Options *PageOptions
+
+	// AttemptTimeout bounds each attempt of the request to the datasource,
+	// i.e. the initial request and each retry.
+	// Optional. Defaults to the timeout passed to NewClient.
+	AttemptTimeout time.Duration
+
+	// OperationTimeout bounds the whole request to the datasource, including
+	// all retries and the delays between them.
+	// Optional. If not set, only the context's deadline applies.
+	OperationTimeout time.Duration
+
+	// RetryPolicy overrides the Datasource's retry policy for the request.
+	// Optional.
+	RetryPolicy *RetryPolicy
+
+	// RequestsPerMinute is the maximum rate of the requests made by the
+	// Datasource, shared with its other requests.
+	// Optional. If zero, Datasource.RequestsPerMinute applies.
+	RequestsPerMinute int
+
+	// StrictPaging indicates whether a page whose `more` flag is inconsistent
+	// with its number of objects is returned as an error rather than logged as
+	// a warning.
+	// Optional. Defaults to false.
+	StrictPaging bool
+
+	// DuplicateIDs is how objects with the same unique ID as a previous object
+	// of the page are handled.
+	// Optional. Defaults to DuplicateIDsDefault.
+	DuplicateIDs DuplicateIDHandling
+
+	// Roles is the list of roles objects must have to be returned, e.g. the
+	// `manager` role of team members. PagerDuty doesn't filter by role, so
+	// objects are filtered after each page is fetched: a page may contain
+	// fewer objects than the page size while more pages remain.
+	// Optional. If not set, objects are not filtered.
+	Roles []string
+
+	// TrimHeavyAttributes indicates whether the entity's large attributes
+	// (e.g. the body and description of incidents) should be removed from
+	// objects to reduce memory usage.
+	// Optional. Defaults to false.
+	TrimHeavyAttributes bool
+
+	// ExpandChildren indicates whether the child objects of each object, e.g.
+	// the members of each team, should be fetched and attached to it, for
+	// entities that have children.
+	// Optional. Defaults to false.
+	ExpandChildren bool
+
+	// ChildConcurrency is the maximum number of objects whose children are
+	// fetched at the same time when ExpandChildren is set.
+	// Optional. Defaults to 4.
+	ChildConcurrency int
+
+	// NormalizeDatetimes indicates whether the entity's datetime attributes
+	// should be reformatted as RFC3339 in UTC, so that values with time zone
+	// offsets or fractional seconds are uniform.
+	// Optional. Defaults to false.
+	NormalizeDatetimes bool
+
+	// NormalizeTimeZones indicates whether the entity's time zone attributes
+	// (e.g. the `support_hours` time zone of services) should be converted
+	// from display names such as "Eastern Time (US & Canada)" to IANA names.
+	// Optional. Defaults to false.
+	NormalizeTimeZones bool
+
+	// NormalizeEnums indicates whether the values of the entity's enum
+	// attributes should be replaced by their canonical values, so that values
+	// are consistent across API versions.
+	// Optional. Defaults to false.
+	NormalizeEnums bool
+
+	// EnumAliases maps the variants of the values of enum attributes to their
+	// canonical values, keyed by attribute then by variant, e.g.
+	// {"role": {"limited_user": "responder"}}, overriding the entity's aliases
+	// of those attributes. Normalizes values even if NormalizeEnums is not
+	// set. Unmapped values are left unchanged.
+	// Optional. If not set, values are not normalized unless NormalizeEnums is
+	// set.
+	EnumAliases map[string]map[string]string
+
+	// DropNoiseAttributes indicates whether the attributes that are mostly
+	// noise for downstream systems (by default `html_url`, `self`, `summary`
+	// and `type`, unless the entity defines its own) should be removed from
+	// objects. The entity's unique ID attribute is never removed.
+	// Optional. Defaults to false.
+	DropNoiseAttributes bool
+
+	// NoiseAttributes overrides the entity's list of attributes removed when
+	// DropNoiseAttributes is set.
+	// Optional.
+	NoiseAttributes []string
+
+	// ResponseMapping locates the objects and paging fields of the responses
+	// with JSON paths, e.g. for endpoints of PagerDuty-compatible APIs, and
+	// defines the endpoint of entities that are not in ValidEntityExternalIDs.
+	// Optional. If nil, responses are parsed as PagerDuty REST API responses.
+	ResponseMapping *ResponseMapping
+
+	// Attributes is the list of attributes to return, which may be JSONPath
+	// expressions, e.g. `$.escalation_policy.id`. The other top-level
+	// attributes of each object are removed, except the unique ID and the
+	// attributes added by the adapter.
+	// Optional. If empty, all attributes are returned.
+	Attributes []string
+
+	// RecordRequestURL indicates whether the URL sent to the datasource should
+	// be returned in Response.RequestURL, e.g. for audit logs.
+	// Optional. Defaults to false.
+	RecordRequestURL bool
+
+	// IdempotencyKey is sent in the Idempotency-Key header of POST requests,
+	// allowing them to be retried like GET requests without risking duplicate
+	// side effects.
+	// Optional. Without it, POST requests are only retried if they could not
+	// be sent.
+	IdempotencyKey string
+
+	// IncludeObjectHash indicates whether a content hash of each object should
+	// be added to the object under the HashAttribute key, so that unchanged
+	// objects can be detected between syncs.
+	// Optional. Defaults to false.
+	IncludeObjectHash bool
+
+	// TagEntity indicates whether the entity external ID should be added to
+	// each object under the EntityAttribute key.
+	// Optional. Defaults to false.
+	TagEntity bool
+
+	// IncludeFetchedAt indicates whether the time the page was fetched should
+	// be added to each object under the FetchedAtAttribute key, e.g. to detect
+	// stale records downstream.
+	// Optional. Defaults to false.
+	IncludeFetchedAt bool
+
+	// Flatten indicates whether nested objects and lists should be flattened
+	// into top-level attributes with the entity's separator and maximum depth.
+	// Optional. Defaults to false.
+	Flatten bool
+
+	// FlattenSeparator is the separator used to flatten nested objects and
+	// lists into top-level attributes, e.g. "." for `parent.id`, overriding
+	// the entity's. Flattens objects even if Flatten is not set.
+	// Optional. If not set, nested values are preserved unless Flatten is set.
+	FlattenSeparator string
+
+	// FlattenMaxDepth is the maximum depth of nested values flattened,
+	// overriding the entity's. Deeper values are kept as-is.
+	// Optional. If 0, the entity's maximum depth is used.
+	FlattenMaxDepth int
 }
 
 // Response is a response returned by the datasource.
@@ -68,6 +268,16 @@ type Response struct {
 	// RetryAfterHeader is the Retry-After response HTTP header, if set.
 	RetryAfterHeader string
 
+	// ErrorCode is the PagerDuty error code of an unsuccessful response, e.g.
+	// 2001 for invalid input.
+	// Zero if the response was successful or its body had no error code.
+	ErrorCode int
+
+	// ErrorMessage is the PagerDuty error description of an unsuccessful
+	// response, including the detailed errors if any.
+	// Empty if the response was successful or its body had no error message.
+	ErrorMessage string
+
 	// Objects is the list of
 	// May be empty.
 	Objects []map[string]any
@@ -76,4 +286,31 @@ type Response struct {
 	// page.
 	// May be empty.
 	NextCursor string
+
+	// HasMore indicates whether the datasource reported more pages after this
+	// one. If false, this is the last page and NextCursor is empty.
+	HasMore bool
+
+	// Total is the total number of objects of the entity reported by the
+	// datasource, or zero if not reported.
+	Total int64
+
+	// RequestID is the X-Request-Id header of the response, identifying the
+	// request for PagerDuty support.
+	RequestID string
+
+	// Retries is the number of times the request was retried before this
+	// response, including rate-limited retries.
+	Retries int
+
+	// RequestURL is the URL that produced this response, with any credentials
+	// removed. Only set if Request.RecordRequestURL is true.
+	RequestURL string
+
+	// HighWaterMark is the latest value of the datetime attribute filtered by
+	// the `since` parameter across the objects of the page, e.g. the creation
+	// time of incidents, for entities that support incremental syncs. The
+	// latest value across all pages is the `since` of the next incremental
+	// sync, which the Adapter adds under the HighWaterMarkAttribute key.
+	HighWaterMark string
 }
@@ -0,0 +1,83 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"sync"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+const (
+	// defaultConcurrency is the maximum number of concurrent requests issued by
+	// batch helpers if not specified.
+	defaultConcurrency = 4
+)
+
+// fanOut calls fetch once for each distinct key, with at most concurrency
+// calls in flight at the same time.
+// Returns the results and the errors, keyed by key. A failed call doesn't stop
+// the other calls.
+func fanOut[T any](
+	ctx context.Context, keys []string, concurrency int, fetch func(ctx context.Context, key string) (T, *framework.Error),
+) (map[string]T, map[string]*framework.Error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]T, len(keys))
+		errs    = make(map[string]*framework.Error)
+		seen    = make(map[string]struct{}, len(keys))
+		slots   = make(chan struct{}, concurrency)
+	)
+
+	for _, key := range keys {
+		if _, found := seen[key]; found {
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		wg.Add(1)
+
+		slots <- struct{}{}
+
+		go func(key string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			result, err := fetch(ctx, key)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs[key] = err
+			} else {
+				results[key] = result
+			}
+		}(key)
+	}
+
+	wg.Wait()
+
+	return results, errs
+}
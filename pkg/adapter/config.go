@@ -17,6 +17,19 @@ package adapter
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// incidentStatuses are the statuses incidents can be filtered by.
+	incidentStatuses = []string{"triggered", "acknowledged", "resolved"}
+
+	// incidentUrgencies are the urgencies incidents can be filtered by.
+	incidentUrgencies = []string{"high", "low"}
 )
 
 // Config is the optional configuration passed in each GetPage calls to the
@@ -26,8 +39,166 @@ type Config struct {
 	// Add/remove fields as needed.
 	// Every field MUST have a `json` tag.
 
-	// Example config field.
+	// APIVersion is the PagerDuty REST API version to request, e.g. "2".
 	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Query filters objects by a name or label substring, for entities that
+	// support it (e.g. tags).
+	Query string `json:"query,omitempty"`
+
+	// ParentID is the ID of the parent object, for parent-scoped entities
+	// (e.g. the incident ID for incident status updates).
+	ParentID string `json:"parentId,omitempty"`
+
+	// Regions are the PagerDuty service regions to sync in order, e.g.
+	// [{"name": "us", "baseUrl": "https://api.pagerduty.com"},
+	// {"name": "eu", "baseUrl": "https://api.eu.pagerduty.com"}], replacing
+	// the address of the datasource.
+	// Optional. If empty, only the address of the datasource is synced.
+	Regions []RegionConfig `json:"regions,omitempty"`
+
+	// ResponseMappings are the JSON path mappings of the responses of
+	// entities, keyed by entity external ID, for endpoints whose responses are
+	// not shaped like those of the PagerDuty REST API. A mapping with a path
+	// defines an entity that is not supported natively.
+	// Optional.
+	ResponseMappings map[string]ResponseMappingConfig `json:"responseMappings,omitempty"`
+
+	// RequestTimeoutSeconds bounds each attempt of the requests to the
+	// datasource, e.g. to allow large pages to be returned by slow responses.
+	// Optional. Defaults to the timeout of the adapter's HTTP client.
+	RequestTimeoutSeconds int `json:"requestTimeoutSeconds,omitempty"`
+
+	// Since is the start of the time window of incremental syncs, as an
+	// RFC3339 timestamp, e.g. the high-water mark of the previous sync, see
+	// HighWaterMarkAttribute. Only applies to entities that support incremental syncs (e.g. incidents, log
+	// entries and audit records).
+	// Optional. If not set, entities are fully synced.
+	Since string `json:"since,omitempty"`
+
+	// Until is the end of the time window of syncs, as an RFC3339 timestamp.
+	// Only applies to entities that support incremental syncs.
+	// Optional. If not set, the time window ends at the time of the sync.
+	Until string `json:"until,omitempty"`
+
+	// Statuses filters incidents by status: triggered, acknowledged or
+	// resolved.
+	// Optional. If empty, incidents of all statuses are synced.
+	Statuses []string `json:"statuses,omitempty"`
+
+	// Urgencies filters incidents by urgency: high or low.
+	// Optional. If empty, incidents of all urgencies are synced.
+	Urgencies []string `json:"urgencies,omitempty"`
+
+	// ServiceIDs filters incidents by the IDs of their services.
+	// Optional. If empty, incidents of all services are synced.
+	ServiceIDs []string `json:"serviceIds,omitempty"`
+
+	// Includes is the list of related resources to embed in each object, e.g.
+	// "escalation_policies" for services, in place of the references to them.
+	// Must be supported by the entity.
+	// Optional.
+	Includes []string `json:"includes,omitempty"`
+
+	// NormalizeDatetimes indicates whether the entity's datetime attributes
+	// should be reformatted as RFC3339 in UTC, failing the page if a value is
+	// not a valid datetime.
+	// Optional. Defaults to false.
+	NormalizeDatetimes bool `json:"normalizeDatetimes,omitempty"`
+
+	// MaxRetries is the maximum number of times a request that failed with a
+	// transient error, e.g. a 503, is retried with exponential backoff.
+	// Optional. If zero, only rate-limited requests are retried.
+	MaxRetries int `json:"maxRetries,omitempty"`
+
+	// RetryBackoffMilliseconds is the delay before the first retry, doubled
+	// for each subsequent retry.
+	// Optional. Defaults to 1000.
+	RetryBackoffMilliseconds int `json:"retryBackoffMilliseconds,omitempty"`
+
+	// RequestsPerMinute is the maximum rate of the requests made to the
+	// datasource, shared by the syncs of all entities, e.g. 960 for the
+	// default PagerDuty rate limit of a token.
+	// Optional. If zero, requests are not rate limited.
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty"`
+
+	// AuthType is how the datasource auth token is sent, either "token" for
+	// API keys or "oauth" for OAuth access tokens.
+	// Optional. Defaults to "token".
+	AuthType string `json:"authType,omitempty"`
+
+	// OAuthClientID and OAuthClientSecret are the credentials of a PagerDuty
+	// app, used to obtain OAuth access tokens with the client credentials
+	// grant in place of the datasource auth token.
+	// Optional. If not set, the datasource auth token is required.
+	OAuthClientID     string `json:"oauthClientId,omitempty"`
+	OAuthClientSecret string `json:"oauthClientSecret,omitempty"`
+
+	// OAuthScopes is the list of scopes requested for the access tokens, e.g.
+	// "as_account-us.example" and "users.read".
+	// Optional.
+	OAuthScopes []string `json:"oauthScopes,omitempty"`
+
+	// OAuthTokenURL is the HTTPS endpoint issuing the access tokens.
+	// Optional. Defaults to the PagerDuty identity service.
+	OAuthTokenURL string `json:"oauthTokenUrl,omitempty"`
+}
+
+// RegionConfig is a PagerDuty service region to sync.
+type RegionConfig struct {
+	// Name identifies the region, e.g. "eu".
+	Name string `json:"name"`
+
+	// BaseURL is the base URL of the region's REST API, e.g.
+	// "https://api.eu.pagerduty.com".
+	BaseURL string `json:"baseUrl"`
+}
+
+// ResponseMappingConfig is the JSON path mapping of the responses of an
+// entity. See ResponseMapping.
+type ResponseMappingConfig struct {
+	// Path is the endpoint path of an entity that is not supported natively,
+	// e.g. "v1/custom/items".
+	Path string `json:"path,omitempty"`
+
+	// UniqueIDAttribute is the unique ID attribute of an entity that is not
+	// supported natively. Defaults to "id".
+	UniqueIDAttribute string `json:"uniqueIdAttribute,omitempty"`
+
+	// ListPath is the JSON path of the list of objects, e.g. "$.data.items".
+	ListPath string `json:"listPath"`
+
+	// MorePath is the JSON path of the boolean indicating that more pages
+	// follow.
+	MorePath string `json:"morePath,omitempty"`
+
+	// NextCursorPath is the JSON path of the cursor of the next page.
+	NextCursorPath string `json:"nextCursorPath,omitempty"`
+
+	// TotalPath is the JSON path of the total number of objects.
+	TotalPath string `json:"totalPath,omitempty"`
+}
+
+// responseMapping returns the response mapping of the entity, or nil if the
+// entity has no mapping.
+func (c *Config) responseMapping(entityExternalID string) *ResponseMapping {
+	if c == nil {
+		return nil
+	}
+
+	mapping, found := c.ResponseMappings[entityExternalID]
+	if !found {
+		return nil
+	}
+
+	return &ResponseMapping{
+		Path:              mapping.Path,
+		UniqueIDAttribute: mapping.UniqueIDAttribute,
+		ListPath:          mapping.ListPath,
+		MorePath:          mapping.MorePath,
+		NextCursorPath:    mapping.NextCursorPath,
+		TotalPath:         mapping.TotalPath,
+	}
 }
 
 // ValidateConfig validates that a Config received in a GetPage call is valid.
@@ -37,9 +208,116 @@ func (c *Config) Validate(_ context.Context) error {
 	switch {
 	case c == nil:
 		return errors.New("request contains no config")
-	case c.APIVersion == "":
-		return errors.New("apiVersion is not set")
+	case c.APIVersion != "" && !apiVersionPattern.MatchString(c.APIVersion):
+		return errors.New("apiVersion must be a version number, e.g. 2")
+	case !validRegions(c.Regions):
+		return errors.New("regions must have unique names and HTTPS base URLs")
+	case !validResponseMappings(c.ResponseMappings):
+		return errors.New("responseMappings must have a listPath, and all their paths must start with $")
+	case c.RequestTimeoutSeconds < 0:
+		return errors.New("requestTimeoutSeconds must not be negative")
+	case c.Since != "" && !isRFC3339(c.Since):
+		return errors.New("since must be an RFC3339 timestamp")
+	case c.Until != "" && !isRFC3339(c.Until):
+		return errors.New("until must be an RFC3339 timestamp")
+	case c.Since != "" && c.Until != "" && !isBefore(c.Since, c.Until):
+		return errors.New("since must be before until")
+	case !allIn(c.Statuses, incidentStatuses):
+		return fmt.Errorf("statuses must be among %s", strings.Join(incidentStatuses, ", "))
+	case !allIn(c.Urgencies, incidentUrgencies):
+		return fmt.Errorf("urgencies must be among %s", strings.Join(incidentUrgencies, ", "))
+	case c.MaxRetries < 0:
+		return errors.New("maxRetries must not be negative")
+	case c.RetryBackoffMilliseconds < 0:
+		return errors.New("retryBackoffMilliseconds must not be negative")
+	case c.RequestsPerMinute < 0:
+		return errors.New("requestsPerMinute must not be negative")
+	case c.AuthType != "" && c.AuthType != AuthTypeToken && c.AuthType != AuthTypeOAuth:
+		return errors.New("authType must be token or oauth")
+	case (c.OAuthClientID == "") != (c.OAuthClientSecret == ""):
+		return errors.New("oauthClientId and oauthClientSecret must be set together")
+	case !c.hasClientCredentials() && (len(c.OAuthScopes) > 0 || c.OAuthTokenURL != ""):
+		return errors.New("oauthScopes and oauthTokenUrl require oauthClientId and oauthClientSecret")
+	case c.OAuthTokenURL != "" && !isHTTPSURL(c.OAuthTokenURL):
+		return errors.New("oauthTokenUrl must be an HTTPS URL")
 	default:
 		return nil
 	}
 }
+
+// hasClientCredentials returns whether OAuth client credentials are
+// configured.
+func (c *Config) hasClientCredentials() bool {
+	return c != nil && c.OAuthClientID != "" && c.OAuthClientSecret != ""
+}
+
+// isHTTPSURL returns whether the value is an absolute HTTPS URL.
+func isHTTPSURL(value string) bool {
+	parsed, err := url.Parse(value)
+
+	return err == nil && parsed.Scheme == "https" && parsed.Host != ""
+}
+
+// isRFC3339 returns whether the value is an RFC3339 timestamp.
+func isRFC3339(value string) bool {
+	_, err := time.Parse(time.RFC3339, value)
+
+	return err == nil
+}
+
+// isBefore returns whether the RFC3339 timestamp a is before b.
+func isBefore(a, b string) bool {
+	timeA, errA := time.Parse(time.RFC3339, a)
+	timeB, errB := time.Parse(time.RFC3339, b)
+
+	return errA == nil && errB == nil && timeA.Before(timeB)
+}
+
+// allIn returns whether all the values are among the allowed values.
+func allIn(values, allowed []string) bool {
+	for _, value := range values {
+		if !slices.Contains(allowed, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validRegions returns whether the regions have unique non-empty names and
+// HTTPS base URLs.
+func validRegions(regions []RegionConfig) bool {
+	names := make(map[string]struct{}, len(regions))
+
+	for _, region := range regions {
+		if _, found := names[region.Name]; found || region.Name == "" {
+			return false
+		}
+
+		names[region.Name] = struct{}{}
+
+		if !isHTTPSURL(region.BaseURL) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validResponseMappings returns whether the response mappings have a list path
+// and all their JSON paths start with the root.
+func validResponseMappings(mappings map[string]ResponseMappingConfig) bool {
+	for _, mapping := range mappings {
+		if mapping.ListPath == "" {
+			return false
+		}
+
+		for _, path := range []string{mapping.ListPath, mapping.MorePath, mapping.NextCursorPath, mapping.TotalPath} {
+			if path != "" && !strings.HasPrefix(path, "$") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
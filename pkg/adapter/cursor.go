@@ -0,0 +1,117 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// pageCursor is the position of a page within an entity, as encoded in the
+// cursors returned by the adapter.
+type pageCursor struct {
+	// Offset is the offset of the first object of the page.
+	Offset int64 `json:"offset,omitempty"`
+
+	// Token is the opaque cursor of the page, for entities using cursor-based
+	// paging instead of offsets.
+	Token string `json:"token,omitempty"`
+
+	// Parent is the cursor of the parent object whose children are being
+	// paged, when paging through the children of all the parents of a
+	// parent-scoped entity. Offset and Token are then the position within
+	// the children of that parent.
+	Parent string `json:"parent,omitempty"`
+
+	// Since is the start of the time window being paged, replacing the
+	// requested since, once paging past the offset cap by slicing the
+	// requested time window. Offset is then the position within that window.
+	Since string `json:"since,omitempty"`
+
+	// Boundary is the comma-separated unique IDs of the objects of the
+	// previous time window whose datetime is Since. PagerDuty returns them
+	// again at the start of the window, so they are skipped.
+	Boundary string `json:"boundary,omitempty"`
+
+	// Region is the name of the region being paged, when paging through
+	// multiple regions. The other fields are then the position within that
+	// region.
+	Region string `json:"region,omitempty"`
+
+	// HighWaterMark is the high-water mark of the pages returned so far by the
+	// Adapter, for entities that support incremental syncs. It is removed from
+	// the cursor before the page is requested from the Client.
+	HighWaterMark string `json:"high_water_mark,omitempty"`
+}
+
+// encodeCursor returns the string form of the cursor.
+//
+// A cursor containing only an offset is encoded as a plain integer, as it
+// always was. Other cursors are encoded as unpadded URL-safe base64 JSON, so
+// that they can be passed back in URL query strings without escaping.
+func encodeCursor(cursor *pageCursor) string {
+	if *cursor == (pageCursor{Offset: cursor.Offset}) {
+		return strconv.FormatInt(cursor.Offset, 10)
+	}
+
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		// Marshaling a struct of basic types cannot fail.
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// parseCursor parses a cursor returned by encodeCursor.
+// An empty cursor is the position of the first page.
+// Padded and standard base64 cursors are also accepted, in case the padding
+// or characters were altered when passed through other systems.
+func parseCursor(cursor string) (*pageCursor, *framework.Error) {
+	if cursor == "" {
+		return &pageCursor{}, nil
+	}
+
+	if offset, err := strconv.ParseInt(cursor, 10, 64); err == nil {
+		return &pageCursor{Offset: offset}, nil
+	}
+
+	normalized := strings.NewReplacer("+", "-", "/", "_").Replace(strings.TrimRight(cursor, "="))
+
+	decoded, err := base64.RawURLEncoding.DecodeString(normalized)
+	if err != nil {
+		return nil, invalidCursorError()
+	}
+
+	parsed := &pageCursor{}
+
+	if err := json.Unmarshal(decoded, parsed); err != nil {
+		return nil, invalidCursorError()
+	}
+
+	return parsed, nil
+}
+
+func invalidCursorError() *framework.Error {
+	return &framework.Error{
+		Message: "Request cursor is malformed.",
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		cursor      *pageCursor
+		wantEncoded string
+	}{
+		"first_page": {
+			cursor:      &pageCursor{},
+			wantEncoded: "0",
+		},
+		"offset": {
+			cursor:      &pageCursor{Offset: 200},
+			wantEncoded: "200",
+		},
+		"token": {
+			cursor: &pageCursor{Token: "dXNlcj0xMjM/Pz8+Pj4="},
+		},
+		"nested": {
+			cursor: &pageCursor{
+				Offset: 25,
+				Parent: encodeCursor(&pageCursor{Token: "a+b/c=="}),
+				Since:  "2024-01-02T03:04:05.123Z",
+				Region: "eu",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoded := encodeCursor(tt.cursor)
+
+			if tt.wantEncoded != "" {
+				AssertDeepEqual(t, tt.wantEncoded, encoded)
+			}
+
+			// The cursor doesn't need escaping in URL query strings.
+			AssertDeepEqual(t, encoded, url.QueryEscape(encoded))
+
+			query := url.Values{"cursor": {encoded}}
+
+			decodedQuery, err := url.ParseQuery(query.Encode())
+			if err != nil {
+				t.Fatalf("Failed to parse query: %v", err)
+			}
+
+			parsed, parseErr := parseCursor(decodedQuery.Get("cursor"))
+			if parseErr != nil {
+				t.Fatalf("Unexpected error: %v", parseErr)
+			}
+
+			AssertDeepEqual(t, tt.cursor, parsed)
+		})
+	}
+}
+
+func TestParseCursorLegacyEncodings(t *testing.T) {
+	cursor := &pageCursor{Token: "a+b/c==", Region: "us"}
+
+	encoded, _ := json.Marshal(cursor)
+
+	for name, value := range map[string]string{
+		"padded_url":   base64.URLEncoding.EncodeToString(encoded),
+		"standard":     base64.StdEncoding.EncodeToString(encoded),
+		"raw_standard": base64.RawStdEncoding.EncodeToString(encoded),
+	} {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := parseCursor(value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, cursor, parsed)
+		})
+	}
+}
+
+func TestParseCursorMalformed(t *testing.T) {
+	for _, cursor := range []string{"not a cursor", "!!!", base64.RawURLEncoding.EncodeToString([]byte("[1]"))} {
+		if _, err := parseCursor(cursor); err == nil {
+			t.Errorf("Expected an error for cursor %q", cursor)
+		}
+	}
+}
@@ -15,12 +15,20 @@
 package adapter
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	framework "github.com/sgnl-ai/adapter-framework"
@@ -30,7 +38,64 @@ import (
 const (
 	// SCAFFOLDING:
 	// Update the set of valid entity types supported by this adapter.
-	Teams string = "teams"
+	Tags               string = "tags"
+	Teams              string = "teams"
+	Users              string = "users"
+	Oncalls            string = "oncalls"
+	Vendors            string = "vendors"
+	Services           string = "services"
+	Incidents          string = "incidents"
+	Schedules          string = "schedules"
+	LogEntries         string = "log_entries"
+	ChangeEvents       string = "change_events"
+	Notifications      string = "notifications"
+	EscalationPolicies string = "escalation_policies"
+	AuditRecords       string = "audit_records"
+	BusinessServices   string = "business_services"
+
+	// Parent-scoped entities, requested with the ID of their parent object.
+	TeamMembers                 string = "team_members"
+	IncidentAlerts              string = "incident_alerts"
+	ScheduleOverrides           string = "schedule_overrides"
+	IncidentSubscribers         string = "incident_subscribers"
+	IncidentStatusUpdates       string = "incident_status_updates"
+	BusinessServiceDependencies string = "business_service_dependencies"
+
+	UserStatusUpdateNotificationRules string = "user_status_update_notification_rules"
+
+	// defaultAPIVersion is the PagerDuty REST API version requested when neither
+	// the entity nor the request specify one.
+	defaultAPIVersion = "2"
+
+	// defaultMaxURLLength is the maximum length of request URLs if the
+	// Datasource doesn't specify one, conservatively below the 8 KiB limit
+	// common to web servers.
+	defaultMaxURLLength = 4096
+
+	// defaultMaxResponseSize is the maximum size of response bodies if the
+	// Datasource doesn't specify one, well above the size of a page of 100
+	// objects with includes.
+	defaultMaxResponseSize = 64 << 20
+
+	// maxOffset is the maximum offset accepted by PagerDuty for offset paging.
+	maxOffset = 10000
+
+	// parentIDPlaceholder is replaced by the parent object ID in the path of
+	// parent-scoped entities.
+	parentIDPlaceholder = "{id}"
+)
+
+// pagingStyle is how the pages of an entity are requested.
+type pagingStyle int
+
+const (
+	// offsetPaging requests pages with the `offset` and `limit` parameters,
+	// and the next page follows the `more` flag of the response.
+	offsetPaging pagingStyle = iota
+
+	// cursorPaging requests pages with the `cursor` and `limit` parameters,
+	// and the next page is the `next_cursor` of the response.
+	cursorPaging
 )
 
 // Entity contains entity specific information, such as the entity's unique ID attribute and the
@@ -42,25 +107,241 @@ type Entity struct {
 
 	// uniqueIDAttrExternalID is the external ID of the entity's uniqueId attribute.
 	uniqueIDAttrExternalID string
+
+	// uniqueIDComponents is the list of attributes whose values make up the
+	// unique ID of objects without an ID of their own, e.g. memberships. If
+	// set, the composite ID is added to each object under
+	// uniqueIDAttrExternalID. For parent-scoped entities, the parent ID is
+	// the first component.
+	// Optional.
+	uniqueIDComponents []string
+
+	// path is the endpoint path of the entity, relative to the BaseURL.
+	// For parent-scoped entities, the path contains the parentIDPlaceholder.
+	// If empty, the entity's external ID is used as the path.
+	path string
+
+	// collectionKey is the field of the datasource response that contains the
+	// list of objects for this entity.
+	collectionKey string
+
+	// objectKey is the field of the datasource response that contains a
+	// single object fetched by ID.
+	// If empty, the entity doesn't support fetching single objects.
+	objectKey string
+
+	// cacheable indicates whether single objects of the entity are static and
+	// can be cached once fetched.
+	cacheable bool
+
+	// supportsQuery indicates whether the entity's endpoint accepts the `query`
+	// parameter to filter objects by a name or label substring.
+	supportsQuery bool
+
+	// hashExcludedAttrs is the list of volatile attributes excluded from the
+	// object hash. If nil, defaultHashExcludedAttrs is used.
+	hashExcludedAttrs []string
+
+	// defaultQuery is the set of query parameters always sent when requesting
+	// the entity, e.g. `total=false`. Overridden by Request.QueryParams.
+	defaultQuery url.Values
+
+	// apiVersion is the PagerDuty REST API version requested for the entity,
+	// overriding Request.APIVersion, e.g. for features only available in an
+	// early-access version.
+	// Optional.
+	apiVersion string
+
+	// requiredQuery is the list of query parameters that requests for the
+	// entity must contain, e.g. the `since` and `until` time window.
+	requiredQuery []string
+
+	// paging is how the entity's endpoint is paged. PagerDuty endpoints
+	// support either offsets or opaque cursors, but not both.
+	// Optional. Defaults to offsetPaging.
+	paging pagingStyle
+
+	// includes is the list of related resources that can be embedded in the
+	// entity's objects with PageOptions.Includes, sent as `include[]`
+	// parameters. Embedded resources replace the references to them.
+	// Optional. If empty, the entity doesn't support includes.
+	includes []string
+
+	// highWaterMarkAttr is the datetime attribute filtered by the `since` and
+	// `until` parameters of the entity's endpoint, whose latest value is
+	// returned in Response.HighWaterMark for incremental syncs.
+	// Optional. If empty, the entity doesn't support incremental syncs.
+	highWaterMarkAttr string
+
+	// windowSort is the sort order, e.g. `created_at:asc`, in which objects
+	// are listed in ascending order of highWaterMarkAttr, allowing objects
+	// beyond the offset cap to be listed by restarting paging in the time
+	// window starting at the latest value of the last page.
+	// Optional. If empty, or if the request is sorted differently, objects
+	// beyond the offset cap cannot be listed.
+	windowSort string
+
+	// datetimeAttrs is the list of datetime attributes normalized to RFC3339
+	// in UTC when Request.NormalizeDatetimes is set.
+	datetimeAttrs []string
+
+	// parentEntity is the external ID of the entity whose objects are the
+	// parents of the objects of a parent-scoped entity, used to discover the
+	// parent IDs with GetAllChildren, or when a page is requested without a
+	// parent ID.
+	// Optional.
+	parentEntity string
+
+	// childEntity is the external ID of the parent-scoped entity whose objects
+	// are fetched for each object and attached under childAttr when
+	// Request.ExpandChildren is set, e.g. the members of teams.
+	// Optional.
+	childEntity string
+
+	// childAttr is the attribute under which the child objects are attached.
+	childAttr string
+
+	// timeZoneAttrs is the list of paths to time zone attributes normalized to
+	// IANA names when Request.NormalizeTimeZones is set.
+	timeZoneAttrs []string
+
+	// flattenSeparator is the separator used to flatten the entity's objects
+	// when Request.Flatten is set, unless overridden by
+	// Request.FlattenSeparator.
+	// Optional. If empty, defaultFlattenSeparator is used.
+	flattenSeparator string
+
+	// flattenMaxDepth is the maximum depth of the nested values flattened when
+	// Request.Flatten is set, unless overridden by Request.FlattenMaxDepth.
+	// Optional. If 0, objects are flattened completely.
+	flattenMaxDepth int
+
+	// enumAliases maps the variants of the values of the entity's enum
+	// attributes to their canonical values, keyed by attribute then by
+	// variant, applied when Request.NormalizeEnums is set. The aliases of an
+	// attribute are overridden by those of Request.EnumAliases, if any.
+	// Optional.
+	enumAliases map[string]map[string]string
+
+	// noiseAttrs is the list of attributes removed from the entity's objects
+	// when Request.DropNoiseAttributes is set, unless overridden by
+	// Request.NoiseAttributes.
+	// Optional. If nil, defaultNoiseAttrs is used.
+	noiseAttrs []string
+
+	// heavyAttrs is the list of large attributes, typically free text, removed
+	// from objects when Request.TrimHeavyAttributes is set. Never contains the
+	// unique ID or status attributes.
+	heavyAttrs []string
+
+	// mapping locates the objects and paging fields of the responses, if set
+	// by Request.ResponseMapping, instead of collectionKey and the paging
+	// fields of DatasourceResponse.
+	mapping *ResponseMapping
 }
 
 // Datasource directly implements a Client interface to allow querying
 // an external datasource.
 type Datasource struct {
 	Client *http.Client
+
+	// TokenProvider provides the Authorization header value for each request,
+	// overriding Request.HTTPAuthorization. Overridden by
+	// Request.TokenProvider.
+	// Optional. If set, a request rejected with a 401 is retried once after
+	// refreshing the token.
+	TokenProvider TokenProvider
+
+	// RetryPolicy configures the retries of failed requests.
+	// Optional. By default, only rate-limited requests are retried.
+	RetryPolicy RetryPolicy
+
+	// MinPageInterval is the minimum delay between the starts of consecutive
+	// page requests when paging through an entity with StreamPages or
+	// GetAllPages.
+	// Optional. By default, pages are requested without delay.
+	MinPageInterval time.Duration
+
+	// MaxURLLength is the maximum length of request URLs. Requests with longer
+	// URLs, e.g. because of many repeated filters, are rejected before being
+	// sent rather than failing with a 414 status code.
+	// Optional. Defaults to 4096.
+	MaxURLLength int
+
+	// MaxResponseSize is the maximum size in bytes of response bodies.
+	// Responses with larger bodies, e.g. because of many included resources,
+	// are rejected rather than exhausting memory.
+	// Optional. Defaults to 64 MiB.
+	MaxResponseSize int64
+
+	// RequestsPerMinute is the maximum rate of the requests made by the
+	// Datasource, shared by all entities, e.g. to stay within the rate limit of
+	// the token when syncing several entities in parallel.
+	// Optional. Overridden by Request.RequestsPerMinute. If zero, requests are
+	// not rate limited.
+	RequestsPerMinute int
+
+	// IncludeFetchedAt indicates whether the time each page was fetched should
+	// be added to the objects of all entities, as if Request.IncludeFetchedAt
+	// was set on every request.
+	// Optional. Defaults to false.
+	IncludeFetchedAt bool
+
+	// Logger logs warnings about the datasource responses.
+	// Optional. If nil, warnings are not logged.
+	Logger *log.Logger
+
+	// Metrics records the metrics of each GetPage call.
+	// Optional. If nil, metrics are not recorded.
+	Metrics Metrics
+
+	// LogRequests indicates whether the metrics of each GetPage call, and the
+	// saturation of the rate limiter when requests wait for it, should be
+	// logged with Logger.
+	// Optional. Defaults to false.
+	LogRequests bool
+
+	// tokenMu ensures concurrent 401s trigger a single token refresh.
+	tokenMu sync.Mutex
+
+	// references caches the single objects of cacheable entities.
+	references referenceCache
+
+	// transport configures the transport of the Client created by NewClient.
+	transport transportOptions
+
+	// timeout adapts the attempt timeout of requests, if enabled with
+	// WithAdaptiveTimeout.
+	timeout adaptiveTimeout
+
+	// limiter limits the rate of requests to RequestsPerMinute.
+	limiter rateLimiter
+
+	// prefetch prefetches the next pages, if enabled with WithPrefetch.
+	prefetch prefetcher
 }
 
+// ClientOption configures the Datasource returned by NewClient.
+type ClientOption func(*Datasource)
+
 type DatasourceResponse struct {
 	// SCAFFOLDING:
 	// Add or remove fields as needed. This should be used to unmarshal the response from the datasource.
 
-	// SCAFFOLDING:
-	// Replace `objects` with the field name in the datasource response that contains the
-	// list of objects. Update the datatype is needed.
-	Objects []map[string]any `json:"teams"`
-	More bool `json:"more"`
-	Limit int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	// Objects is read from the field named by the entity's collectionKey, so it
+	// is not unmarshaled directly.
+	Objects []map[string]any `json:"-"`
+	More    bool             `json:"more"`
+	Limit   int64            `json:"limit"`
+	Offset  int64            `json:"offset"`
+
+	// NextCursor is the cursor of the next page returned by endpoints using
+	// cursor-based paging. Null or absent on the last page.
+	NextCursor string `json:"next_cursor"`
+
+	// Total is the total number of objects, only returned by PagerDuty if
+	// requested with `total=true`.
+	Total int64 `json:"total"`
 }
 
 type Team struct {
@@ -68,6 +349,9 @@ type Team struct {
 }
 
 var (
+	// apiVersionPattern matches valid API versions, e.g. "2" or "2.1".
+	apiVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
 	// SCAFFOLDING:
 	// Using the consts defined above, update the set of valid entity types supported by this adapter.
 
@@ -76,127 +360,962 @@ var (
 	ValidEntityExternalIDs = map[string]Entity{
 		Teams: {
 			uniqueIDAttrExternalID: "id",
+			collectionKey:          "teams",
+			objectKey:              "team",
+			childEntity:            TeamMembers,
+			childAttr:              MembersAttribute,
+		},
+		Users: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "users",
+			objectKey:              "user",
+			timeZoneAttrs:          []string{"time_zone"},
+			includes:               []string{"contact_methods", "notification_rules", "teams"},
+		},
+		Services: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "services",
+			objectKey:              "service",
+			datetimeAttrs:          []string{"created_at", "last_incident_timestamp"},
+			timeZoneAttrs:          []string{"support_hours.time_zone"},
+			includes:               []string{"escalation_policies", "teams", "integrations"},
+		},
+		BusinessServices: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "business_services",
+			objectKey:              "business_service",
+		},
+		Incidents: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "incidents",
+			// The incident body and description contain free text of arbitrary
+			// length, and the first trigger log entry embeds a whole log entry.
+			heavyAttrs:        []string{"body", "description", "first_trigger_log_entry"},
+			datetimeAttrs:     []string{"created_at", "updated_at", "last_status_change_at", "resolved_at"},
+			highWaterMarkAttr: "created_at",
+			defaultQuery:      url.Values{"sort_by": {"created_at:asc"}},
+			windowSort:        "created_at:asc",
+			includes: []string{
+				"acknowledgers", "agents", "assignees", "conference_bridge", "escalation_policies",
+				"first_trigger_log_entries", "priorities", "services", "teams", "users",
+			},
+			// The summary of incidents is their title rather than noise.
+			noiseAttrs: []string{"html_url", "self", "type"},
+		},
+		Schedules: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "schedules",
+			objectKey:              "schedule",
+			timeZoneAttrs:          []string{"time_zone"},
+		},
+		Vendors: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "vendors",
+			objectKey:              "vendor",
+			cacheable:              true,
+		},
+		Oncalls: {
+			// On-call entries have no ID of their own.
+			collectionKey: "oncalls",
+			// On-calls are computed by PagerDuty from the final schedules,
+			// which include overrides. With `earliest` disabled, all the
+			// entries of the since/until window are returned rather than only
+			// the earliest of each user and escalation level, so an override
+			// covering only part of the window is returned as an entry of the
+			// overriding user for its sub-window, between the entries of the
+			// scheduled user. The entries are returned as is, neither split nor
+			// merged by the adapter.
+			defaultQuery: url.Values{
+				"earliest":  {"false"},
+				"time_zone": {"UTC"},
+			},
+			// Permanent on-calls have null start and end.
+			datetimeAttrs: []string{"start", "end"},
+			includes:      []string{"escalation_policies", "schedules", "users"},
+		},
+		LogEntries: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "log_entries",
+			objectKey:              "log_entry",
+			// Without the include, log entries only reference the channel that
+			// triggered them by type rather than embedding its details, e.g.
+			// the email subject or API client. Entries without a channel, such as
+			// acknowledgements, are returned as is.
+			defaultQuery: url.Values{
+				"include[]": {"channels"},
+			},
+			datetimeAttrs:     []string{"created_at"},
+			highWaterMarkAttr: "created_at",
+			includes:          []string{"channels", "incidents", "services", "teams"},
+		},
+		ChangeEvents: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "change_events",
+			datetimeAttrs:          []string{"timestamp"},
+		},
+		Tags: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "tags",
+			supportsQuery:          true,
+		},
+		EscalationPolicies: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "escalation_policies",
+			objectKey:              "escalation_policy",
+			includes:               []string{"services", "teams", "targets"},
+		},
+		AuditRecords: {
+			uniqueIDAttrExternalID: "id",
+			path:                   "audit/records",
+			collectionKey:          "records",
+			paging:                 cursorPaging,
+			highWaterMarkAttr:      "execution_time",
+		},
+		IncidentStatusUpdates: {
+			uniqueIDAttrExternalID: "id",
+			path:                   "incidents/{id}/status_updates",
+			collectionKey:          "status_updates",
+			datetimeAttrs:          []string{"created_at"},
+			parentEntity:           Incidents,
+		},
+		IncidentAlerts: {
+			uniqueIDAttrExternalID: "id",
+			path:                   "incidents/{id}/alerts",
+			collectionKey:          "alerts",
+			objectKey:              "alert",
+			// The alert body embeds the whole event payload, including its
+			// `details`, which can be arbitrarily large.
+			heavyAttrs:    []string{"body"},
+			datetimeAttrs: []string{"created_at"},
+			parentEntity:  Incidents,
+		},
+		IncidentSubscribers: {
+			uniqueIDAttrExternalID: "subscriber_id",
+			path:                   "incidents/{id}/status_updates/subscribers",
+			collectionKey:          "subscribers",
+			parentEntity:           Incidents,
+		},
+		TeamMembers: {
+			// Team memberships have no ID of their own, and are unique by team
+			// and user.
+			uniqueIDAttrExternalID: "id",
+			uniqueIDComponents:     []string{"user.id"},
+			path:                   "teams/{id}/members",
+			collectionKey:          "members",
+			parentEntity:           Teams,
+		},
+		BusinessServiceDependencies: {
+			uniqueIDAttrExternalID: "id",
+			path:                   "service_dependencies/business_services/{id}",
+			collectionKey:          "relationships",
+			parentEntity:           BusinessServices,
+		},
+		UserStatusUpdateNotificationRules: {
+			// A user without such rules yields an empty page, while an unknown
+			// user yields a not-found error.
+			uniqueIDAttrExternalID: "id",
+			path:                   "users/{id}/status_update_notification_rules",
+			collectionKey:          "status_update_notification_rules",
+			parentEntity:           Users,
+		},
+		ScheduleOverrides: {
+			uniqueIDAttrExternalID: "id",
+			path:                   "schedules/{id}/overrides",
+			collectionKey:          "overrides",
+			requiredQuery:          []string{"since", "until"},
+			datetimeAttrs:          []string{"start", "end"},
+			parentEntity:           Schedules,
+		},
+		Notifications: {
+			uniqueIDAttrExternalID: "id",
+			collectionKey:          "notifications",
+			requiredQuery:          []string{"since", "until"},
+			datetimeAttrs:          []string{"started_at"},
 		},
 	}
 )
 
 // NewClient returns a Client to query the datasource.
-func NewClient(timeout int) Client {
-	return &Datasource{
+func NewClient(timeout int, opts ...ClientOption) Client {
+	d := &Datasource{
 		Client: &http.Client{
 			Timeout: time.Duration(timeout) * time.Second,
 		},
+		Logger: log.New(os.Stdout, "adapter", log.Lmicroseconds|log.LUTC|log.Lshortfile),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	d.Client.Transport = d.transport.roundTripper()
+
+	return d
+}
+
+// WithMaxResponseSize sets the maximum size in bytes of response bodies.
+func WithMaxResponseSize(maxSize int64) ClientOption {
+	return func(d *Datasource) {
+		d.MaxResponseSize = maxSize
+	}
+}
+
+// WithMaxURLLength sets the maximum length of request URLs.
+func WithMaxURLLength(maxLength int) ClientOption {
+	return func(d *Datasource) {
+		d.MaxURLLength = maxLength
 	}
 }
 
 func (d *Datasource) GetPage(ctx context.Context, request *Request) (*Response, *framework.Error) {
-	var req *http.Request
-	// SCAFFOLDING:
-	// Populate the request with the appropriate path, headers, and query parameters to query the
-	// datasource.
-	offset, offsetErr := parseCursor(request.Cursor)
-	if offsetErr != nil {
-		return nil, offsetErr
+	if d.prefetch.enabled() {
+		return d.instrument(ctx, request, d.getPageWithPrefetch)
 	}
-	url := fmt.Sprintf("%s/%s?offset=%d&limit=%d", request.BaseURL, request.EntityExternalID, offset, request.PageSize)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
+	return d.instrument(ctx, request, d.getPage)
+}
+
+func (d *Datasource) getPage(ctx context.Context, request *Request) (*Response, *framework.Error) {
+	entity, found := ValidEntityExternalIDs[request.EntityExternalID]
+	if !found {
+		entity, found = mappedEntity(request.ResponseMapping)
+	}
+
+	if !found {
 		return nil, &framework.Error{
-			Message: "Failed to create HTTP request to datasource.",
-			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+			Message: fmt.Sprintf("Provided entity external ID is not supported: %s.", request.EntityExternalID),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
 		}
 	}
 
-	// Timeout API calls that take longer than 5 seconds
-	apiCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	if request.ResponseMapping != nil {
+		entity.mapping = request.ResponseMapping
+	}
 
-	req = req.WithContext(apiCtx)
+	if requestErr := validateRequest(request); requestErr != nil {
+		return nil, requestErr
+	}
 
-	// SCAFFOLDING:
-	// Add headers to the request, if any.
-	req.Header.Add("Accept", "application/vnd.pagerduty+json;version=2")
-	req.Header.Add("Authorization", request.HTTPAuthorization)
-	req.Header.Add("Content-Type", "application/json")
+	cursor, cursorErr := parseCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
 
-	res, err := d.Client.Do(req)
-	if err != nil {
-		return nil, &framework.Error{
-			Message: "Failed to send request to datasource.",
-			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+	if len(request.Regions) > 0 {
+		return d.getRegionsPage(ctx, request, cursor)
+	}
+
+	if entity.isParentScoped() && request.ParentID == "" && entity.parentEntity != "" {
+		return d.getChildrenPage(ctx, request, entity, cursor)
+	}
+
+	query := pageQuery(entity, request, cursor)
+
+	if queryErr := validateRequiredQuery(entity, query); queryErr != nil {
+		return nil, queryErr
+	}
+
+	if includesErr := validateIncludes(entity, request.Options); includesErr != nil {
+		return nil, includesErr
+	}
+
+	if query.Has("since") && query.Has("until") {
+		if windowErr := validateTimeWindow(query); windowErr != nil {
+			return nil, windowErr
 		}
 	}
 
-	response := &Response{
-		StatusCode:       res.StatusCode,
-		RetryAfterHeader: res.Header.Get("Retry-After"),
+	requestURL, urlErr := pageURL(entity, request, query)
+	if urlErr != nil {
+		return nil, urlErr
 	}
 
-	if res.StatusCode != http.StatusOK {
+	if lengthErr := d.validateURLLength(requestURL); lengthErr != nil {
+		return nil, lengthErr
+	}
+
+	fetchedAt := time.Now()
+
+	// The page is decoded as it is read from the response body.
+	var data *DatasourceResponse
+
+	response, body, doErr := d.doDecode(ctx, request, http.MethodGet, requestURL, nil, func(body io.Reader) *framework.Error {
+		var decodeErr *framework.Error
+
+		data, decodeErr = decodePage(body, entity, cursor.Offset)
+
+		return decodeErr
+	})
+	if doErr != nil {
+		return nil, doErr
+	}
+
+	// A parent-scoped entity returns a 404 if the parent object doesn't exist,
+	// while a parent without child objects returns an empty page.
+	// The response is returned together with the error, so that its status
+	// code remains available, e.g. to HTTPStatus.
+	if response.StatusCode == http.StatusNotFound && entity.isParentScoped() {
+		return response, notFoundError(request.EntityExternalID+" parent", request.ParentID)
+	}
+
+	if response.StatusCode == http.StatusBadRequest && request.Cursor != "" && isInvalidCursorError(body) {
+		return response, cursorExpiredError()
+	}
+
+	if response.StatusCode != http.StatusOK {
 		return response, nil
 	}
 
-	defer res.Body.Close()
+	var parseOpts []ParseOption
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, &framework.Error{
-			Message: "Failed to read response body.",
-			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+	if len(entity.uniqueIDComponents) > 0 {
+		var prefixes []string
+		if entity.isParentScoped() {
+			prefixes = append(prefixes, request.ParentID)
+		}
+
+		parseOpts = append(parseOpts, WithCompositeID(entity.uniqueIDAttrExternalID, entity.uniqueIDComponents, prefixes...))
+	}
+
+	if entity.uniqueIDAttrExternalID != "" {
+		duplicateIDs := request.DuplicateIDs
+		if duplicateIDs == DuplicateIDsDefault && request.StrictPaging {
+			duplicateIDs = DuplicateIDsError
+		}
+
+		parseOpts = append(parseOpts, WithDuplicateIDHandling(entity.uniqueIDAttrExternalID, duplicateIDs))
+	}
+
+	if request.TrimHeavyAttributes && len(entity.heavyAttrs) > 0 {
+		parseOpts = append(parseOpts, WithoutAttributes(entity.heavyAttrs...))
+	}
+
+	// Enums are normalized before hashing so that the hash doesn't change
+	// with the API version.
+	if aliases := enumAliases(entity, request); len(aliases) > 0 {
+		parseOpts = append(parseOpts, WithEnumNormalization(aliases))
+	}
+
+	if request.NormalizeDatetimes && len(entity.datetimeAttrs) > 0 {
+		parseOpts = append(parseOpts, WithDatetimeNormalization(entity.datetimeAttrs...))
+	}
+
+	if request.NormalizeTimeZones && len(entity.timeZoneAttrs) > 0 {
+		parseOpts = append(parseOpts, WithTimeZoneNormalization(entity.timeZoneAttrs...))
+	}
+
+	if request.IncludeObjectHash {
+		excluded := entity.hashExcludedAttrs
+		if excluded == nil {
+			excluded = defaultHashExcludedAttrs
 		}
+
+		parseOpts = append(parseOpts, WithObjectHash(excluded...))
+	}
+
+	// Noise attributes are dropped after hashing so that the hash doesn't
+	// depend on whether they are.
+	if request.DropNoiseAttributes {
+		noise := request.NoiseAttributes
+		if noise == nil {
+			noise = entity.noiseAttrs
+		}
+
+		if noise == nil {
+			noise = defaultNoiseAttrs
+		}
+
+		noise = slices.DeleteFunc(slices.Clone(noise), func(attribute string) bool {
+			return attribute == entity.uniqueIDAttrExternalID
+		})
+
+		parseOpts = append(parseOpts, WithoutAttributes(noise...))
+	}
+
+	// Objects are projected after hashing, so that the hash doesn't depend on
+	// the requested attributes, and before synthetic attributes are added.
+	// The attributes used after parsing are kept as well.
+	if len(request.Attributes) > 0 {
+		attributes := append(slices.Clone(request.Attributes), entity.uniqueIDAttrExternalID)
+
+		if entity.highWaterMarkAttr != "" {
+			attributes = append(attributes, entity.highWaterMarkAttr)
+		}
+
+		if len(request.Roles) > 0 {
+			attributes = append(attributes, "role")
+		}
+
+		parseOpts = append(parseOpts, WithAttributeProjection(attributes...))
+	}
+
+	if separator, maxDepth := flattening(entity, request); separator != "" {
+		parseOpts = append(parseOpts, WithFlattening(separator, maxDepth))
+	}
+
+	if request.TagEntity {
+		parseOpts = append(parseOpts, WithEntityTag(request.EntityExternalID))
+	}
+
+	if request.IncludeFetchedAt || d.IncludeFetchedAt {
+		parseOpts = append(parseOpts, WithFetchedAt(fetchedAt))
 	}
 
-	objects, nextCursor, parseErr := ParseResponse(body)
+	objects, nextCursor, total, parseErr := parsePage(data, entity, parseOpts...)
 	if parseErr != nil {
 		return nil, parseErr
 	}
 
+	response.Total = total
+
+	if entity.windowSort != "" {
+		if nextCursor, parseErr = windowCursor(entity, query, cursor, nextCursor); parseErr != nil {
+			return nil, parseErr
+		}
+	}
+
 	response.Objects = objects
 	response.NextCursor = nextCursor
+	response.HasMore = nextCursor != ""
+
+	if entity.highWaterMarkAttr != "" {
+		response.HighWaterMark = highWaterMark(objects, entity.highWaterMarkAttr)
+	}
+
+	if pagingErr := checkPagingConsistency(len(objects), response.HasMore, request.PageSize); pagingErr != nil {
+		if request.StrictPaging {
+			return nil, pagingErr
+		}
+
+		d.logf("Warning: %s", pagingErr.Message)
+	}
+
+	// Filtering by role happens after checking the page against the `more`
+	// flag, since it doesn't affect paging.
+	if len(request.Roles) > 0 {
+		matchesRole := hasRole(request.Roles)
+
+		response.Objects = slices.DeleteFunc(response.Objects, func(object map[string]any) bool {
+			return !matchesRole(object)
+		})
+	}
+
+	// The objects at the boundary of two time windows were already returned
+	// with the previous window.
+	if cursor.Boundary != "" {
+		response.Objects = withoutBoundary(response.Objects, entity, cursor)
+	}
+
+	if request.ExpandChildren && entity.childEntity != "" {
+		if childErr := d.expandChildren(ctx, request, entity, response.Objects); childErr != nil {
+			return nil, childErr
+		}
+	}
 
 	return response, nil
 }
 
-func parseCursor(cursor string) (int64, *framework.Error) {
-	if cursor == "" {
-		// Return a default value, or handle the case as needed
-		return 0, nil
+// logf logs a message with the Datasource's Logger, if set.
+func (d *Datasource) logf(format string, v ...any) {
+	if d.Logger != nil {
+		d.Logger.Printf(format, v...)
 	}
+}
 
-	parsedOffset, parseErr := strconv.ParseInt(cursor, 10, 64)
-	if parseErr != nil {
-		// Handle the error if parsing fails
-		return 0, &framework.Error{
-			Message: "Request cursor conversion to int64 failed.",
+// pageURL returns the URL to request a page of the entity with the given page
+// query parameters.
+// Query parameters already present in the BaseURL are preserved, except those
+// overridden by the page query parameters (e.g. offset and limit).
+func pageURL(entity Entity, request *Request, pageQuery url.Values) (string, *framework.Error) {
+	baseURL, err := url.Parse(request.BaseURL)
+	if err != nil {
+		return "", &framework.Error{
+			Message: fmt.Sprintf("Provided datasource address is invalid: %v.", err),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+		}
+	}
+
+	path, pathErr := entityPath(entity, request)
+	if pathErr != nil {
+		return "", pathErr
+	}
+
+	query := baseURL.Query()
+
+	for key, values := range pageQuery {
+		query[key] = values
+	}
+
+	// The path is already escaped, e.g. the parent ID of parent-scoped
+	// entities.
+	baseURL = baseURL.JoinPath(path)
+	baseURL.RawQuery = query.Encode()
+
+	return baseURL.String(), nil
+}
+
+// entityPath returns the endpoint path of the entity, with the request's
+// ParentID substituted for parent-scoped entities.
+func entityPath(entity Entity, request *Request) (string, *framework.Error) {
+	if entity.path == "" {
+		return request.EntityExternalID, nil
+	}
+
+	if !entity.isParentScoped() {
+		return entity.path, nil
+	}
+
+	if request.ParentID == "" {
+		return "", &framework.Error{
+			Message: fmt.Sprintf("Entity %s requires a parent ID.", request.EntityExternalID),
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
 		}
 	}
 
-	return parsedOffset, nil
+	return strings.Replace(entity.path, parentIDPlaceholder, url.PathEscape(request.ParentID), 1), nil
 }
 
-func ParseResponse(body []byte) (objects []map[string]any, nextCursor string, err *framework.Error) {
-	var data *DatasourceResponse
+// isParentScoped returns whether the entity's objects are listed per parent
+// object, e.g. the status updates of an incident.
+func (e Entity) isParentScoped() bool {
+	return strings.Contains(e.path, parentIDPlaceholder)
+}
+
+// pageQuery returns the query parameters to request a page of the entity.
+// See PageOptions for the precedence of the query parameters.
+func pageQuery(entity Entity, request *Request, cursor *pageCursor) url.Values {
+	query := url.Values{}
+	query.Set("limit", strconv.FormatInt(request.PageSize, 10))
+
+	// The first page of cursor-paged entities is requested without a cursor.
+	// A cursor token returned for an offset-paged entity, e.g. by an endpoint
+	// migrated to cursor paging, takes precedence over the offset.
+	switch {
+	case cursor.Token != "":
+		query.Set("cursor", cursor.Token)
+	case entity.paging == offsetPaging:
+		query.Set("offset", strconv.FormatInt(cursor.Offset, 10))
+	}
+
+	for key, values := range entity.defaultQuery {
+		query[key] = values
+	}
+
+	mergeQuery(query, request.Options.query())
+	mergeQuery(query, request.QueryParams)
+
+	// The time window of a cursor replaces the requested since.
+	if cursor.Since != "" {
+		query.Set("since", cursor.Since)
+	}
+
+	// The query filter is omitted when empty so that the unfiltered list is returned.
+	if entity.supportsQuery && request.Query != "" {
+		query.Set("query", request.Query)
+	}
+
+	return query
+}
+
+// mergeQuery sets the parameters of the query to the non-empty values of the
+// given parameters, e.g. `statuses[]=triggered&statuses[]=acknowledged` for
+// repeated filters. Parameters without non-empty values are omitted rather than
+// sent empty, which PagerDuty may reject or interpret as matching nothing.
+func mergeQuery(query url.Values, params map[string][]string) {
+	for key, values := range params {
+		values = slices.DeleteFunc(slices.Clone(values), func(value string) bool {
+			return value == ""
+		})
 
-	unmarshalErr := json.Unmarshal(body, &data)
-	if unmarshalErr != nil {
-		return nil, "", &framework.Error{
-			Message: fmt.Sprintf("Failed to unmarshal the datasource response: %v.", unmarshalErr),
+		if len(values) > 0 {
+			query[key] = values
+		}
+	}
+}
+
+// bodyDecoder decodes the body of a successful response as it is read.
+type bodyDecoder func(body io.Reader) *framework.Error
+
+// do sends an HTTP request with the given method, URL and optional JSON body to
+// the datasource.
+// Returns the response and its body, which contains the error description if
+// the request didn't succeed.
+func (d *Datasource) do(
+	ctx context.Context, request *Request, method, requestURL string, payload []byte,
+) (*Response, []byte, *framework.Error) {
+	return d.doDecode(ctx, request, method, requestURL, payload, nil)
+}
+
+// doDecode sends an HTTP request like do, but if decode is set, the body of a
+// successful response is decoded by it as it is read instead of being
+// returned. An attempt whose body fails to be read is retried, and decode is
+// then called again.
+func (d *Datasource) doDecode(
+	ctx context.Context, request *Request, method, requestURL string, payload []byte, decode bodyDecoder,
+) (*Response, []byte, *framework.Error) {
+	// Retries share the operation timeout, while each attempt is bounded by the
+	// attempt timeout.
+	opCtx := ctx
+
+	if request.OperationTimeout > 0 {
+		var cancel context.CancelFunc
+
+		opCtx, cancel = context.WithTimeout(ctx, request.OperationTimeout)
+		defer cancel()
+	}
+
+	// Attempts default to the timeout of the Client, e.g. the timeout passed to
+	// NewClient.
+	attemptTimeout := request.AttemptTimeout
+	if attemptTimeout <= 0 {
+		attemptTimeout = d.Client.Timeout
+	}
+
+	policy := d.retryPolicy(request)
+
+	var (
+		retries, rateLimitRetries int
+		rateLimitWaited           time.Duration
+	)
+
+	for {
+		if limitErr := d.waitForRateLimit(opCtx, request); limitErr != nil {
+			return nil, nil, limitErr
+		}
+
+		timeout := d.timeout.get(attemptTimeout)
+
+		// The attempt timeout is clamped to the deadline of the context.
+		clamped := false
+
+		if deadline, ok := opCtx.Deadline(); ok && (timeout <= 0 || time.Until(deadline) < timeout) {
+			timeout, clamped = time.Until(deadline), true
+		}
+
+		var (
+			attemptCtx context.Context
+			cancel     context.CancelFunc
+		)
+
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(opCtx, timeout)
+		} else {
+			attemptCtx, cancel = context.WithCancel(opCtx)
+		}
+
+		response, body, err := d.doOnce(attemptCtx, request, method, requestURL, payload, decode)
+
+		// Attempts also time out while reading the body, e.g. of large pages,
+		// which is reported as a truncated body rather than a timeout.
+		timedOut := isTimeout(err) || err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		if response != nil {
+			response.Retries = retries + rateLimitRetries
+		}
+
+		// Attempts interrupted by the operation timeout or the deadline of the
+		// context are not slow.
+		if opCtx.Err() == nil && !(clamped && timedOut) {
+			d.timeout.observe(attemptTimeout, timeout, timedOut)
+		}
+
+		// Rate-limited requests are rejected before being executed, so they are
+		// retried regardless of the method, within their own budget.
+		if err == nil && response.StatusCode == http.StatusTooManyRequests {
+			delay := policy.rateLimitDelay(response, rateLimitRetries)
+
+			if rateLimitRetries >= policy.maxRateLimitRetries() ||
+				rateLimitWaited+delay > policy.maxRateLimitWait() {
+				return response, body, nil
+			}
+
+			if waitErr := wait(opCtx, delay); waitErr != nil {
+				return response, body, nil
+			}
+
+			rateLimitRetries++
+			rateLimitWaited += delay
+
+			continue
+		}
+
+		// A body truncated mid-stream or a refused connection is transient, so
+		// the request is retried.
+		retryable := err != nil && err.Code == api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE
+		if err == nil {
+			retryable = policy.retryable(response, body)
+		}
+
+		// A request that may have been executed by the datasource is only
+		// retried if repeating it has no additional side effects.
+		if !isConnectionRefused(err) && !idempotent(method, request) {
+			retryable = false
+		}
+
+		if retries >= policy.MaxRetries || !retryable {
+			return response, body, err
+		}
+
+		if waitErr := wait(opCtx, policy.retryDelay(response, retries)); waitErr != nil {
+			return response, body, err
+		}
+
+		retries++
+	}
+}
+
+// doOnce sends a single HTTP request to the datasource, decoding the body of a
+// successful response with decode if set.
+func (d *Datasource) doOnce(
+	ctx context.Context, request *Request, method, requestURL string, payload []byte, decode bodyDecoder,
+) (*Response, []byte, *framework.Error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reqBody)
+	if err != nil {
+		return nil, nil, &framework.Error{
+			Message: "Failed to create HTTP request to datasource.",
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
 		}
 	}
 
-	// SCAFFOLDING:
-	// Add necessary validations to check if the response from the datasource is what is expected.
+	apiVersion, versionErr := requestAPIVersion(request)
+	if versionErr != nil {
+		return nil, nil, versionErr
+	}
+
+	req.Header.Add("Accept", "application/vnd.pagerduty+json;version="+apiVersion)
+	req.Header.Add("Content-Type", "application/json")
 
+	if request.IdempotencyKey != "" && method != http.MethodGet {
+		req.Header.Add("Idempotency-Key", request.IdempotencyKey)
+	}
 
-	// SCAFFOLDING:
-	// Populate nextCursor with the cursor returned from the datasource, if present.
+	res, sendErr := d.send(req, request)
+	if sendErr != nil {
+		return nil, nil, sendErr
+	}
+
+	defer res.Body.Close()
+
+	resBody, decodeErr := decodedBody(res)
+	if decodeErr != nil {
+		return nil, nil, truncatedBodyError(decodeErr)
+	}
+
+	response := &Response{
+		StatusCode:       res.StatusCode,
+		RetryAfterHeader: res.Header.Get("Retry-After"),
+		RequestID:        res.Header.Get("X-Request-Id"),
+	}
+
+	if request.RecordRequestURL {
+		response.RequestURL = redactURL(req.URL)
+	}
+
+	// The body of an unsuccessful response only contains an error description,
+	// so it is read on a best-effort basis.
+	if res.StatusCode != http.StatusOK {
+		errorBody, _ := io.ReadAll(io.LimitReader(resBody, maxErrorBodySize))
+
+		if parsed, ok := parseErrorBody(errorBody); ok {
+			response.ErrorCode = parsed.Error.Code
+			response.ErrorMessage = parsed.message()
+		}
+
+		return response, errorBody, nil
+	}
+
+	maxSize := d.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxResponseSize
+	}
+
+	limited := &sizeLimitedReader{r: resBody, limit: maxSize}
+
+	if decode != nil {
+		if decodeErr := decode(limited); decodeErr != nil {
+			return nil, nil, decodeErr
+		}
+
+		return response, nil, nil
+	}
+
+	body, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, nil, decodeError(err)
+	}
+
+	return response, body, nil
+}
+
+// requestAPIVersion returns the API version to request: the entity's version if
+// set, otherwise the request's version, otherwise the default version.
+func requestAPIVersion(request *Request) (string, *framework.Error) {
+	version := defaultAPIVersion
+
+	if request.APIVersion != "" {
+		version = request.APIVersion
+	}
 
+	if entity, found := ValidEntityExternalIDs[request.EntityExternalID]; found && entity.apiVersion != "" {
+		version = entity.apiVersion
+	}
+
+	if !apiVersionPattern.MatchString(version) {
+		return "", &framework.Error{
+			Message: fmt.Sprintf("Provided API version is invalid: %s.", version),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+		}
+	}
+
+	return version, nil
+}
+
+// redactURL returns the string form of the URL without any user credentials.
+// The Authorization header is never part of the URL, but a BaseURL may embed
+// userinfo which must not be recorded.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+
+	return redacted.String()
+}
+
+// ParseResponse parses a datasource response body, extracting the list of objects
+// from the field named by the entity's collectionKey.
+// The options are applied to the parsed objects in order.
+func ParseResponse(
+	body []byte, entity Entity, opts ...ParseOption,
+) (objects []map[string]any, nextCursor string, err *framework.Error) {
+	data, err := decodePage(bytes.NewReader(body), entity, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	objects, nextCursor, _, err = parsePage(data, entity, opts...)
+
+	return objects, nextCursor, err
+}
+
+// decodePage decodes a datasource response body as it is read, given the
+// offset of the request.
+func decodePage(body io.Reader, entity Entity, offset int64) (*DatasourceResponse, *framework.Error) {
+	var (
+		data      *DatasourceResponse
+		found     bool
+		decodeErr *framework.Error
+		listName  = entity.collectionKey
+	)
+
+	if entity.mapping != nil {
+		listName = entity.mapping.ListPath
+		data, found, decodeErr = decodeMappedResponse(body, entity.mapping, offset)
+	} else {
+		data, found, decodeErr = decodeResponse(body, entity.collectionKey)
+	}
+
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	// A missing collection means the response is not a page of the entity,
+	// unlike an empty collection, which is a valid empty page.
+	if !found {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Datasource response is missing the %s field.", listName),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	}
+
+	return data, nil
+}
+
+// parsePage extracts the objects and the next cursor of a decoded datasource
+// response, and also returns the total number of objects reported by the
+// datasource, if any.
+// The options are applied to the objects in order.
+func parsePage(
+	data *DatasourceResponse, entity Entity, opts ...ParseOption,
+) (objects []map[string]any, nextCursor string, total int64, err *framework.Error) {
+	objects, transformErr := transformObjects(data.Objects, opts)
+	if transformErr != nil {
+		return nil, "", 0, transformErr
+	}
+
+	// Objects are validated once transformed, since composite unique IDs are
+	// only set by the transformations.
+	if validationErr := validateUniqueIDs(objects, entity.uniqueIDAttrExternalID); validationErr != nil {
+		return nil, "", 0, validationErr
+	}
+
+	// The paging style is detected from the response, as the next cursor is
+	// only returned by endpoints using cursor-based paging. Their last page
+	// has neither a next cursor nor `more` set.
 	nextCursor = ""
-	if data.More {
-		nextCursor = strconv.FormatInt(data.Offset+data.Limit, 10)
+
+	switch {
+	case data.NextCursor != "":
+		nextCursor = encodeCursor(&pageCursor{Token: data.NextCursor})
+	case data.More && entity.paging == offsetPaging:
+		// PagerDuty rejects offsets beyond its cap, so the remaining objects
+		// can only be listed by narrowing the request, e.g. its time window.
+		// Entities sorted by their high-water mark continue in the time window
+		// starting at the latest value of the page instead.
+		if data.Offset+data.Limit >= maxOffset {
+			since := ""
+			if entity.windowSort != "" {
+				since = highWaterMark(objects, entity.highWaterMarkAttr)
+			}
+
+			if since == "" {
+				return nil, "", 0, offsetCapError()
+			}
+
+			nextCursor = encodeCursor(&pageCursor{Since: since, Boundary: boundaryIDs(objects, entity, since)})
+
+			break
+		}
+
+		nextCursor = encodeCursor(&pageCursor{Offset: data.Offset + data.Limit})
 	}
-	return data.Objects, nextCursor, nil
+
+	return objects, nextCursor, data.Total, nil
+}
+
+// highWaterMark returns the latest value of the datetime attribute across the
+// objects, as RFC3339 in UTC, or an empty string if no object has a valid
+// value.
+func highWaterMark(objects []map[string]any, attribute string) string {
+	var latest time.Time
+
+	for _, object := range objects {
+		value, _ := object[attribute].(string)
+
+		if datetime, err := time.Parse(time.RFC3339Nano, value); err == nil && datetime.After(latest) {
+			latest = datetime
+		}
+	}
+
+	if latest.IsZero() {
+		return ""
+	}
+
+	return latest.UTC().Format(time.RFC3339Nano)
+}
+
+// latestDatetime returns the latest of the RFC3339 datetimes, ignoring empty
+// or invalid values.
+func latestDatetime(datetimes ...string) string {
+	objects := make([]map[string]any, 0, len(datetimes))
+
+	for _, datetime := range datetimes {
+		objects = append(objects, map[string]any{"datetime": datetime})
+	}
+
+	return highWaterMark(objects, "datetime")
 }
@@ -0,0 +1,533 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestPageURL(t *testing.T) {
+	tests := map[string]struct {
+		baseURL  string
+		entity   string
+		parentID string
+		query    url.Values
+		wantURL  string
+	}{
+		"no_query": {
+			baseURL: "https://api.pagerduty.com",
+			entity:  Users,
+			query:   url.Values{"limit": {"100"}, "offset": {"0"}},
+			wantURL: "https://api.pagerduty.com/users?limit=100&offset=0",
+		},
+		"base_url_query_preserved": {
+			baseURL: "https://api.pagerduty.com/?region=us",
+			entity:  Users,
+			query:   url.Values{"limit": {"100"}, "offset": {"0"}},
+			wantURL: "https://api.pagerduty.com/users?limit=100&offset=0&region=us",
+		},
+		"base_url_paging_overridden": {
+			baseURL: "https://api.pagerduty.com/v2?limit=5&offset=7&region=us",
+			entity:  Users,
+			query:   url.Values{"limit": {"100"}, "offset": {"200"}},
+			wantURL: "https://api.pagerduty.com/v2/users?limit=100&offset=200&region=us",
+		},
+		"parent_scoped": {
+			baseURL:  "https://api.pagerduty.com?region=us",
+			entity:   IncidentAlerts,
+			parentID: "Q1",
+			query:    url.Values{"limit": {"100"}},
+			wantURL:  "https://api.pagerduty.com/incidents/Q1/alerts?limit=100&region=us",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := &Request{BaseURL: tt.baseURL, EntityExternalID: tt.entity, ParentID: tt.parentID}
+
+			gotURL, err := pageURL(ValidEntityExternalIDs[tt.entity], request, tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, tt.wantURL, gotURL)
+		})
+	}
+}
+
+func TestGetPageBaseURLQuery(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The paging parameters of the base URL are replaced, and the others
+		// are preserved.
+		AssertDeepEqual(t, url.Values{
+			"limit":  {"2"},
+			"offset": {"0"},
+			"region": {"us"},
+		}, r.URL.Query())
+
+		w.Write([]byte(`{"users":[{"id":"U1"},{"id":"U2"}],"more":true,"limit":2,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Users)
+	request.BaseURL = server.URL + "?region=us&limit=5&offset=7"
+	request.PageSize = 2
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "U1"}, {"id": "U2"}}, response.Objects)
+	AssertDeepEqual(t, "2", response.NextCursor)
+}
+
+func TestParseResponse(t *testing.T) {
+	tests := map[string]struct {
+		body           string
+		wantObjects    []map[string]any
+		wantNextCursor string
+		wantErr        *framework.Error
+	}{
+		"page": {
+			body:           `{"users":[{"id":"U1"},{"id":"U2"}],"more":true,"limit":2,"offset":4}`,
+			wantObjects:    []map[string]any{{"id": "U1"}, {"id": "U2"}},
+			wantNextCursor: "6",
+		},
+		"empty_page": {
+			body:        `{"users":[],"more":false,"limit":2,"offset":0}`,
+			wantObjects: []map[string]any{},
+		},
+		"missing_id": {
+			body: `{"users":[{"id":"U1"},{"name":"No ID"}],"more":false}`,
+			wantErr: &framework.Error{
+				Message: "Datasource response object at index 1 is missing the unique ID attribute id.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			},
+		},
+		"empty_id": {
+			body: `{"users":[{"id":""}],"more":false}`,
+			wantErr: &framework.Error{
+				Message: "Datasource response object at index 0 is missing the unique ID attribute id.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			},
+		},
+		"missing_array": {
+			body: `{"teams":[{"id":"T1"}],"more":false}`,
+			wantErr: &framework.Error{
+				Message: "Datasource response is missing the users field.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			objects, nextCursor, err := ParseResponse([]byte(tt.body), ValidEntityExternalIDs[Users])
+
+			AssertDeepEqual(t, tt.wantErr, err)
+			AssertDeepEqual(t, tt.wantObjects, objects)
+			AssertDeepEqual(t, tt.wantNextCursor, nextCursor)
+		})
+	}
+}
+
+func TestGetPageQueryFilters(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// Parameters are encoded sorted by name, repeated parameters in order,
+		// and empty filters are omitted.
+		AssertDeepEqual(t, "/incidents", r.URL.Path)
+		AssertDeepEqual(t,
+			"include%5B%5D=services&limit=100&offset=0&since=2024-01-01T00%3A00%3A00Z&sort_by=created_at%3Aasc"+
+				"&statuses%5B%5D=triggered&statuses%5B%5D=acknowledged&until=2024-01-02T00%3A00%3A00Z",
+			r.URL.RawQuery)
+
+		w.Write([]byte(`{"incidents":[],"more":false,"limit":100,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Incidents)
+	request.QueryParams = map[string][]string{
+		"since":         {"2024-01-01T00:00:00Z"},
+		"until":         {"2024-01-02T00:00:00Z"},
+		"statuses[]":    {"triggered", "acknowledged"},
+		"urgencies[]":   {},
+		"service_ids[]": {""},
+		"include[]":     {"services"},
+	}
+
+	if _, err := NewClient(5).GetPage(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestGetPageEntityCollections(t *testing.T) {
+	tests := map[string]struct {
+		entity   string
+		wantPath string
+		body     string
+	}{
+		"users": {
+			entity:   Users,
+			wantPath: "/users",
+			body:     `{"users":[{"id":"P1"}],"more":false}`,
+		},
+		"services": {
+			entity:   Services,
+			wantPath: "/services",
+			body:     `{"services":[{"id":"P1"}],"more":false}`,
+		},
+		"schedules": {
+			entity:   Schedules,
+			wantPath: "/schedules",
+			body:     `{"schedules":[{"id":"P1"}],"more":false}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				AssertDeepEqual(t, tt.wantPath, r.URL.Path)
+
+				w.Write([]byte(tt.body))
+			})
+
+			response, err := NewClient(5).GetPage(context.Background(), newTestRequest(server, tt.entity))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, []map[string]any{{"id": "P1"}}, response.Objects)
+			AssertDeepEqual(t, "", response.NextCursor)
+		})
+	}
+}
+
+func TestGetPageTagsQuery(t *testing.T) {
+	tests := map[string]struct {
+		entity       string
+		query        string
+		cursor       string
+		wantRawQuery string
+		wantQuery    string
+	}{
+		"label": {
+			entity:       Tags,
+			query:        "prod",
+			wantRawQuery: "limit=100&offset=0&query=prod",
+			wantQuery:    "prod",
+		},
+		"next_page": {
+			entity:       Tags,
+			query:        "prod",
+			cursor:       "100",
+			wantRawQuery: "limit=100&offset=100&query=prod",
+			wantQuery:    "prod",
+		},
+		"label_with_spaces_and_unicode": {
+			entity:       Tags,
+			query:        "on call & équipe",
+			wantRawQuery: "limit=100&offset=0&query=on+call+%26+%C3%A9quipe",
+			wantQuery:    "on call & équipe",
+		},
+		"empty": {
+			entity:       Tags,
+			wantRawQuery: "limit=100&offset=0",
+		},
+		"unsupported_entity": {
+			entity:       Teams,
+			query:        "prod",
+			wantRawQuery: "limit=100&offset=0",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				AssertDeepEqual(t, tt.wantRawQuery, r.URL.RawQuery)
+				AssertDeepEqual(t, tt.wantQuery, r.URL.Query().Get("query"))
+
+				w.Write([]byte(`{"` + tt.entity + `":[{"id":"P1"}],"more":false}`))
+			})
+
+			request := newTestRequest(server, tt.entity)
+			request.Query = tt.query
+			request.Cursor = tt.cursor
+
+			if _, err := NewClient(5).GetPage(context.Background(), request); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPageQueryPrecedence(t *testing.T) {
+	entity := Entity{
+		paging:       offsetPaging,
+		defaultQuery: url.Values{"limit": {"25"}, "sort_by": {"created_at:asc"}, "total": {"false"}},
+	}
+
+	tests := map[string]struct {
+		params    map[string][]string
+		wantQuery url.Values
+	}{
+		"entity_defaults_over_paging": {
+			wantQuery: url.Values{
+				"limit":   {"25"},
+				"offset":  {"40"},
+				"sort_by": {"created_at:asc"},
+				"total":   {"false"},
+			},
+		},
+		"request_params_over_entity_defaults": {
+			params: map[string][]string{"sort_by": {"created_at:desc"}, "total": {"true"}},
+			wantQuery: url.Values{
+				"limit":   {"25"},
+				"offset":  {"40"},
+				"sort_by": {"created_at:desc"},
+				"total":   {"true"},
+			},
+		},
+		"request_params_over_paging": {
+			params: map[string][]string{"limit": {"10"}, "offset": {"20"}},
+			wantQuery: url.Values{
+				"limit":   {"10"},
+				"offset":  {"20"},
+				"sort_by": {"created_at:asc"},
+				"total":   {"false"},
+			},
+		},
+		"empty_request_params_ignored": {
+			params: map[string][]string{"sort_by": {""}, "total": {}},
+			wantQuery: url.Values{
+				"limit":   {"25"},
+				"offset":  {"40"},
+				"sort_by": {"created_at:asc"},
+				"total":   {"false"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := &Request{PageSize: 100, QueryParams: tt.params}
+
+			AssertDeepEqual(t, tt.wantQuery, pageQuery(entity, request, &pageCursor{Offset: 40}))
+		})
+	}
+}
+
+func TestGetPageChangeEvents(t *testing.T) {
+	tests := map[string]struct {
+		body        string
+		wantObjects []map[string]any
+		wantCursor  string
+		wantErr     *framework.Error
+	}{
+		"page": {
+			body:        `{"change_events":[{"id":"C1","timestamp":"2024-01-01T12:00:00Z"}],"more":true,"limit":1,"offset":0}`,
+			wantObjects: []map[string]any{{"id": "C1", "timestamp": "2024-01-01T12:00:00Z"}},
+			wantCursor:  "1",
+		},
+		"offset_cap": {
+			body: `{"change_events":[{"id":"C1","timestamp":"2024-01-01T12:00:00Z"}],"more":true,"limit":1,"offset":9999}`,
+			wantErr: &framework.Error{
+				Message: "Datasource cannot return objects beyond offset 10000. Narrow the request, e.g. its since/until time window.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				AssertDeepEqual(t, "/change_events", r.URL.Path)
+				AssertDeepEqual(t, url.Values{
+					"limit":         {"1"},
+					"offset":        {"0"},
+					"service_ids[]": {"S1", "S2"},
+					"since":         {"2024-01-01T00:00:00Z"},
+					"until":         {"2024-01-02T00:00:00Z"},
+				}, r.URL.Query())
+
+				w.Write([]byte(tt.body))
+			})
+
+			request := newTestRequest(server, ChangeEvents)
+			request.PageSize = 1
+			request.QueryParams = map[string][]string{
+				"since":         {"2024-01-01T00:00:00Z"},
+				"until":         {"2024-01-02T00:00:00Z"},
+				"service_ids[]": {"S1", "S2"},
+			}
+
+			response, err := NewClient(5).GetPage(context.Background(), request)
+
+			AssertDeepEqual(t, tt.wantErr, err)
+
+			if err == nil {
+				AssertDeepEqual(t, tt.wantObjects, response.Objects)
+				AssertDeepEqual(t, tt.wantCursor, response.NextCursor)
+			}
+		})
+	}
+}
+
+func TestGetPageOncallsOverride(t *testing.T) {
+	// The override of U2 covers the middle of the window scheduled for U1.
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		AssertDeepEqual(t, "/oncalls", r.URL.Path)
+		AssertDeepEqual(t, url.Values{
+			"earliest":  {"false"},
+			"limit":     {"100"},
+			"offset":    {"0"},
+			"since":     {"2024-01-01T00:00:00Z"},
+			"time_zone": {"UTC"},
+			"until":     {"2024-01-02T00:00:00Z"},
+		}, r.URL.Query())
+
+		w.Write([]byte(`{"oncalls":[` +
+			`{"user":{"id":"U1"},"escalation_level":1,"start":"2024-01-01T00:00:00Z","end":"2024-01-01T08:00:00Z"},` +
+			`{"user":{"id":"U2"},"escalation_level":1,"start":"2024-01-01T08:00:00Z","end":"2024-01-01T12:00:00Z"},` +
+			`{"user":{"id":"U1"},"escalation_level":1,"start":"2024-01-01T12:00:00Z","end":"2024-01-02T00:00:00Z"}` +
+			`],"more":false,"limit":100,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Oncalls)
+	request.QueryParams = map[string][]string{
+		"since": {"2024-01-01T00:00:00Z"},
+		"until": {"2024-01-02T00:00:00Z"},
+	}
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The entries of each sub-window are returned with their own start and
+	// end.
+	AssertDeepEqual(t, []map[string]any{
+		{
+			"user":             map[string]any{"id": "U1"},
+			"escalation_level": float64(1),
+			"start":            "2024-01-01T00:00:00Z",
+			"end":              "2024-01-01T08:00:00Z",
+		},
+		{
+			"user":             map[string]any{"id": "U2"},
+			"escalation_level": float64(1),
+			"start":            "2024-01-01T08:00:00Z",
+			"end":              "2024-01-01T12:00:00Z",
+		},
+		{
+			"user":             map[string]any{"id": "U1"},
+			"escalation_level": float64(1),
+			"start":            "2024-01-01T12:00:00Z",
+			"end":              "2024-01-02T00:00:00Z",
+		},
+	}, response.Objects)
+}
+
+func TestGetPageIncidentsOffsetCapWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Three incidents are created every second, so that incidents share their
+	// creation time at the boundary of the time windows.
+	incidents := make([]map[string]any, 12000)
+	for i := range incidents {
+		incidents[i] = map[string]any{
+			"id":         fmt.Sprintf("Q%d", i),
+			"created_at": start.Add(time.Duration(i/3) * time.Second).Format(time.RFC3339),
+		}
+	}
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		since, _ := time.Parse(time.RFC3339, query.Get("since"))
+
+		AssertDeepEqual(t, "created_at:asc", query.Get("sort_by"))
+
+		// The since of the window is inclusive.
+		window := slices.IndexFunc(incidents, func(incident map[string]any) bool {
+			createdAt, _ := time.Parse(time.RFC3339, incident["created_at"].(string))
+
+			return !createdAt.Before(since)
+		})
+
+		page := incidents[min(window+offset, len(incidents)):min(window+offset+limit, len(incidents))]
+
+		body, _ := json.Marshal(map[string]any{
+			"incidents": page,
+			"more":      window+offset+limit < len(incidents),
+			"limit":     limit,
+			"offset":    offset,
+		})
+
+		w.Write(body)
+	})
+
+	request := newTestRequest(server, Incidents)
+	request.Options = &PageOptions{Since: start}
+
+	client := NewClient(5)
+
+	var (
+		ids     []string
+		cursors []string
+	)
+
+	for {
+		response, err := client.GetPage(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, incident := range response.Objects {
+			ids = append(ids, incident["id"].(string))
+		}
+
+		if response.NextCursor == "" {
+			break
+		}
+
+		cursors = append(cursors, response.NextCursor)
+		request.Cursor = response.NextCursor
+	}
+
+	// The offset cap is hit after the 100th page, so paging continues in the
+	// window starting at the creation time of the last incident, without the
+	// incident of that time that was already returned.
+	AssertDeepEqual(t, "9900", cursors[98])
+	AssertDeepEqual(t, encodeCursor(&pageCursor{Since: "2024-01-01T00:55:33Z", Boundary: "Q9999"}), cursors[99])
+	AssertDeepEqual(t, encodeCursor(&pageCursor{Offset: 100, Since: "2024-01-01T00:55:33Z", Boundary: "Q9999"}), cursors[100])
+
+	wantIDs := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		wantIDs = append(wantIDs, incident["id"].(string))
+	}
+
+	AssertDeepEqual(t, wantIDs, ids)
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+var (
+	// errUnexpectedJSON is returned when a response body is valid JSON but
+	// not shaped as expected.
+	errUnexpectedJSON = errors.New("unexpected JSON")
+)
+
+// responseTooLargeError is returned by a sizeLimitedReader once more than its
+// limit has been read.
+type responseTooLargeError struct {
+	limit int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeds %d bytes", e.limit)
+}
+
+// sizeLimitedReader reads from a response body until more than limit bytes
+// have been read, then fails with a *responseTooLargeError, so that the body
+// is never read without bound.
+type sizeLimitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.read > l.limit {
+		return 0, &responseTooLargeError{limit: l.limit}
+	}
+
+	// At most one byte past the limit is read, to detect larger bodies.
+	if remaining := l.limit - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+
+	if l.read > l.limit {
+		return n, &responseTooLargeError{limit: l.limit}
+	}
+
+	return n, err
+}
+
+// decodeResponse decodes a datasource response body as it is read from the
+// stream, decoding the objects of the collection one at a time, so that large
+// pages are never held in memory as raw bytes.
+// Returns false if the body has no collection field.
+func decodeResponse(body io.Reader, collectionKey string) (*DatasourceResponse, bool, *framework.Error) {
+	decoder := json.NewDecoder(body)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, false, decodeError(err)
+	}
+
+	var (
+		data   DatasourceResponse
+		found  bool
+		fields = make(map[string]json.RawMessage)
+	)
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false, decodeError(err)
+		}
+
+		key, _ := token.(string)
+
+		if key != collectionKey {
+			var value json.RawMessage
+
+			if err := decoder.Decode(&value); err != nil {
+				return nil, false, decodeError(err)
+			}
+
+			fields[key] = value
+
+			continue
+		}
+
+		found = true
+
+		if data.Objects, err = decodeObjects(decoder); err != nil {
+			if !isInvalidJSON(err) {
+				return nil, false, decodeError(err)
+			}
+
+			return nil, false, &framework.Error{
+				Message: fmt.Sprintf("Failed to unmarshal the datasource response field %s: %v.", collectionKey, err),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+			}
+		}
+	}
+
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, false, decodeError(err)
+	}
+
+	// The other fields are few and small, e.g. the paging fields, so they are
+	// unmarshaled as a whole.
+	if len(fields) > 0 {
+		encoded, err := json.Marshal(fields)
+		if err == nil {
+			err = json.Unmarshal(encoded, &data)
+		}
+
+		if err != nil {
+			return nil, false, decodeError(err)
+		}
+	}
+
+	return &data, found, nil
+}
+
+// decodeObjects decodes a JSON array of objects, one object at a time.
+// A null array is decoded as nil.
+func decodeObjects(decoder *json.Decoder) ([]map[string]any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, nil
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("%w: expected an array, got %v", errUnexpectedJSON, token)
+	}
+
+	objects := []map[string]any{}
+
+	for decoder.More() {
+		var object map[string]any
+
+		if err := decoder.Decode(&object); err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	// Consume the closing bracket.
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// expectDelim consumes the next token of the decoder, which must be the given
+// delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("%w: expected %v, got %v", errUnexpectedJSON, delim, token)
+	}
+
+	return nil
+}
+
+// isInvalidJSON returns whether the decoding error was caused by the content
+// of the body, as opposed to the stream it was read from.
+func isInvalidJSON(err error) bool {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	return errors.Is(err, errUnexpectedJSON) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// decodeError returns the error for a response body that could not be decoded.
+// A body ending mid-stream, e.g. cut off by a proxy, or failing to be read is
+// transient, unlike an empty or invalid body.
+func decodeError(err error) *framework.Error {
+	var tooLargeErr *responseTooLargeError
+
+	switch {
+	case errors.As(err, &tooLargeErr):
+		return &framework.Error{
+			Message: fmt.Sprintf("Datasource response exceeds the maximum size of %d bytes. Reduce the page size or the included resources.", tooLargeErr.limit),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	case errors.Is(err, io.EOF):
+		return &framework.Error{
+			Message: "Datasource response is empty.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	case isInvalidJSON(err):
+		return &framework.Error{
+			Message: fmt.Sprintf("Failed to unmarshal the datasource response: %v.", err),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+		}
+	default:
+		return truncatedBodyError(err)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	tests := map[string]struct {
+		body      string
+		wantData  *DatasourceResponse
+		wantFound bool
+		wantCode  api_adapter_v1.ErrorCode
+	}{
+		"objects_and_paging": {
+			body: `{"users":[{"id":"U1"},{"id":"U2"}],"more":true,"limit":2,"offset":0}`,
+			wantData: &DatasourceResponse{
+				Objects: []map[string]any{{"id": "U1"}, {"id": "U2"}},
+				More:    true,
+				Limit:   2,
+			},
+			wantFound: true,
+		},
+		"missing_collection": {
+			body:     `{"more":false}`,
+			wantData: &DatasourceResponse{},
+		},
+		"empty_body": {
+			body:     "",
+			wantCode: api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		},
+		"truncated_body": {
+			body:     `{"users":[{"id":"U1"},{"id":`,
+			wantCode: api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+		},
+		"invalid_body": {
+			body:     `{"users":{"id":"U1"}}`,
+			wantCode: api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, found, err := decodeResponse(strings.NewReader(tt.body), "users")
+
+			if tt.wantCode != 0 {
+				if err == nil {
+					t.Fatalf("Expected error code %v, got nil", tt.wantCode)
+				}
+
+				AssertDeepEqual(t, tt.wantCode, err.Code)
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, tt.wantData, data)
+			AssertDeepEqual(t, tt.wantFound, found)
+		})
+	}
+}
+
+func TestSizeLimitedReader(t *testing.T) {
+	reader := &sizeLimitedReader{r: strings.NewReader("0123456789"), limit: 5}
+
+	body, err := io.ReadAll(reader)
+
+	AssertDeepEqual(t, "012345", string(body))
+
+	if err == nil || decodeError(err).Code != api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG {
+		t.Errorf("Expected the size guard error, got %v", err)
+	}
+
+	reader = &sizeLimitedReader{r: strings.NewReader("01234"), limit: 5}
+
+	body, err = io.ReadAll(reader)
+
+	AssertDeepEqual(t, "01234", string(body))
+	AssertDeepEqual(t, nil, err)
+}
+
+func TestGetPageMaxResponseSize(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"U1","name":"` + strings.Repeat("x", 1024) + `"}],"more":false}`))
+	})
+
+	client := NewClient(5, WithMaxResponseSize(512))
+
+	_, err := client.GetPage(context.Background(), newTestRequest(server, Users))
+	if err == nil {
+		t.Fatal("Expected an error for a response over the maximum size")
+	}
+
+	AssertDeepEqual(t, api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG, err.Code)
+}
+
+func TestGetPageStreamedResponse(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"U1"},{"id":"U2"}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	client := NewClient(5)
+
+	response, err := client.GetPage(context.Background(), newTestRequest(server, Users))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, http.StatusOK, response.StatusCode)
+	AssertDeepEqual(t, []map[string]any{{"id": "U1"}, {"id": "U2"}}, response.Objects)
+	AssertDeepEqual(t, "", response.NextCursor)
+}
+
+func TestGetPageGzipDroppedConnection(t *testing.T) {
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(`{"users":[{"id":"U1","name":"` + strings.Repeat("x", 4096) + `"},{"id":"U2"}],"more":false}`))
+	writer.Close()
+
+	var requests atomic.Int32
+
+	// The gzip body is flushed in chunks, and the connection of the first
+	// request is dropped halfway through.
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		dropped := requests.Add(1) == 1
+
+		w.Header().Set("Content-Encoding", "gzip")
+
+		body := compressed.Bytes()
+
+		for i := 0; i < len(body); i += 16 {
+			if dropped && i >= len(body)/2 {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+
+				return
+			}
+
+			w.Write(body[i:min(i+16, len(body))])
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	request := newTestRequest(server, Users)
+	request.RetryPolicy = &RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "U1", "name": strings.Repeat("x", 4096)}, {"id": "U2"}}, response.Objects)
+	AssertDeepEqual(t, 1, response.Retries)
+	AssertDeepEqual(t, int32(2), requests.Load())
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"slices"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// EntityValidationResult is the result of validating that an entity can be
+// queried.
+type EntityValidationResult struct {
+	// Err is the error returned when requesting the entity.
+	// Nil if the entity was queried successfully or skipped.
+	Err *framework.Error
+
+	// Skipped is the reason why the entity was not queried, e.g. because it
+	// requires a parent ID.
+	// Empty if the entity was queried.
+	Skipped string
+}
+
+// ValidateAllEntities requests a single object of each entity in
+// ValidEntityExternalIDs, using the base URL and credentials of the request,
+// to verify that each entity is reachable, e.g. not gated by the account's
+// plan.
+// Entities that cannot be requested without additional parameters (parent ID,
+// required query parameters) are skipped.
+// If skipForbidden is true, entities the token lacks the scope to access are
+// logged and skipped instead of failing, so that least-privilege tokens only
+// report errors for the entities they are expected to access. Other 403
+// errors are still reported.
+// At most concurrency entities are requested at the same time.
+func (d *Datasource) ValidateAllEntities(
+	ctx context.Context, request *Request, concurrency int, skipForbidden bool,
+) map[string]EntityValidationResult {
+	entityIDs := make([]string, 0, len(ValidEntityExternalIDs))
+
+	for entityID := range ValidEntityExternalIDs {
+		entityIDs = append(entityIDs, entityID)
+	}
+
+	slices.Sort(entityIDs)
+
+	results, _ := fanOut(ctx, entityIDs, concurrency, func(ctx context.Context, entityID string) (EntityValidationResult, *framework.Error) {
+		entity := ValidEntityExternalIDs[entityID]
+
+		switch {
+		case entity.isParentScoped():
+			return EntityValidationResult{Skipped: "Entity requires a parent ID."}, nil
+		case len(entity.requiredQuery) > 0:
+			return EntityValidationResult{Skipped: "Entity requires query parameters."}, nil
+		}
+
+		entityRequest := *request
+		entityRequest.EntityExternalID = entityID
+		entityRequest.Attributes = nil
+		entityRequest.PageSize = 1
+		entityRequest.Cursor = ""
+
+		response, err := d.GetPage(ctx, &entityRequest)
+		if err != nil {
+			return EntityValidationResult{Err: err}, nil
+		}
+
+		if skipForbidden && isInsufficientScope(response) {
+			d.logf("Skipping entity %s: token lacks the required scope: %s", entityID, response.ErrorMessage)
+
+			return EntityValidationResult{Skipped: "Token lacks the scope required to access the entity."}, nil
+		}
+
+		return EntityValidationResult{Err: responseError(response)}, nil
+	})
+
+	return results
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// forbiddenHandler returns a handler rejecting the users for the token's lack
+// of scope, and the teams for another access restriction mentioning the scope.
+func forbiddenHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"message":"Insufficient scope","code":2010}}`))
+		case "/teams":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"message":"Teams are outside the scope of the account's plan","code":2100}}`))
+		default:
+			w.Write([]byte(`{"more":false}`))
+		}
+	}
+}
+
+// forbiddenTeamsError is the error for the teams rejected by forbiddenHandler.
+var forbiddenTeamsError = &framework.Error{
+	Message: "Access forbidden by datasource. Check datasource configuration details and try again. " +
+		"Datasource error 2100: Teams are outside the scope of the account's plan.",
+	Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+}
+
+func TestValidateAllEntitiesForbidden(t *testing.T) {
+	server := newTestServer(t, forbiddenHandler())
+
+	datasource := NewClient(5).(*Datasource)
+
+	tests := map[string]struct {
+		skipForbidden bool
+		wantUsers     EntityValidationResult
+	}{
+		"skip_forbidden": {
+			skipForbidden: true,
+			wantUsers:     EntityValidationResult{Skipped: "Token lacks the scope required to access the entity."},
+		},
+		"report_forbidden": {
+			wantUsers: EntityValidationResult{Err: &framework.Error{
+				Message: "Access forbidden by datasource. Check datasource configuration details and try again. " +
+					"Datasource error 2010: Insufficient scope.",
+				Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+			}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			results := datasource.ValidateAllEntities(context.Background(), newTestRequest(server, Users), 4, tt.skipForbidden)
+
+			// Only the insufficient scope is skipped.
+			AssertDeepEqual(t, tt.wantUsers, results[Users])
+			AssertDeepEqual(t, EntityValidationResult{Err: forbiddenTeamsError}, results[Teams])
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// GetAllChildren returns all the objects of the requested parent-scoped entity
+// across all its parents, keyed by parent ID, e.g. the members of all teams,
+// without the caller enumerating the parents.
+//
+// The parent entity is first listed to discover the parent IDs, without the
+// request's query parameters and options, which only apply to the children.
+// The children of at most concurrency parents are then fetched at the same
+// time. Errors are returned per parent ID, and a parent failing doesn't prevent
+// the children of the other parents from being returned.
+func (d *Datasource) GetAllChildren(
+	ctx context.Context, request *Request, concurrency int,
+) (map[string][]map[string]any, map[string]*framework.Error, *framework.Error) {
+	entity, found := ValidEntityExternalIDs[request.EntityExternalID]
+	if !found || entity.parentEntity == "" {
+		return nil, nil, &framework.Error{
+			Message: fmt.Sprintf("Provided entity external ID does not support parent discovery: %s.", request.EntityExternalID),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+		}
+	}
+
+	parentRequest := *request
+	parentRequest.EntityExternalID = entity.parentEntity
+	parentRequest.ParentID = ""
+	parentRequest.Cursor = ""
+	parentRequest.Query = ""
+	parentRequest.QueryParams = nil
+	parentRequest.Options = nil
+	parentRequest.Roles = nil
+	parentRequest.Attributes = nil
+	parentRequest.ExpandChildren = false
+
+	parents, err := d.GetAllPages(ctx, &parentRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parentIDAttr := ValidEntityExternalIDs[entity.parentEntity].uniqueIDAttrExternalID
+	parentIDs := make([]string, 0, len(parents.Objects))
+
+	for _, parent := range parents.Objects {
+		if id, ok := parent[parentIDAttr].(string); ok && id != "" {
+			parentIDs = append(parentIDs, id)
+		}
+	}
+
+	children, errs := fanOut(ctx, parentIDs, concurrency, func(ctx context.Context, parentID string) ([]map[string]any, *framework.Error) {
+		childRequest := *request
+		childRequest.ParentID = parentID
+		childRequest.Cursor = ""
+
+		result, err := d.GetAllPages(ctx, &childRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		return result.Objects, nil
+	})
+
+	return children, errs, nil
+}
+
+// getChildrenPage returns a page of the children of all the parents of the
+// requested parent-scoped entity, for requests without a parent ID, e.g. the
+// members of all teams.
+//
+// Parents are listed one at a time, and each page contains the children of a
+// single parent. The returned cursor combines the cursor of the parent with the
+// position within its children, so that paging can resume mid-parent.
+func (d *Datasource) getChildrenPage(
+	ctx context.Context, request *Request, entity Entity, cursor *pageCursor,
+) (*Response, *framework.Error) {
+	parentRequest := *request
+	parentRequest.EntityExternalID = entity.parentEntity
+	parentRequest.PageSize = 1
+	parentRequest.Cursor = cursor.Parent
+	parentRequest.Query = ""
+	parentRequest.QueryParams = nil
+	parentRequest.Options = nil
+	parentRequest.Roles = nil
+	parentRequest.Attributes = nil
+	parentRequest.ExpandChildren = false
+
+	parents, err := d.GetPage(ctx, &parentRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if parents.StatusCode != http.StatusOK || len(parents.Objects) == 0 {
+		// The last parent has no parent after it.
+		parents.NextCursor = ""
+
+		return parents, nil
+	}
+
+	parentID, _ := parents.Objects[0][ValidEntityExternalIDs[entity.parentEntity].uniqueIDAttrExternalID].(string)
+
+	childRequest := *request
+	childRequest.ParentID = parentID
+	childRequest.Cursor = encodeCursor(&pageCursor{Offset: cursor.Offset, Token: cursor.Token})
+
+	children, err := d.GetPage(ctx, &childRequest)
+	if err != nil {
+		// The parent may have been deleted since it was listed.
+		if !IsNotFound(err) {
+			return nil, err
+		}
+
+		children = &Response{StatusCode: http.StatusOK, Objects: []map[string]any{}}
+	}
+
+	if children.StatusCode != http.StatusOK {
+		return children, nil
+	}
+
+	switch {
+	case children.NextCursor != "":
+		childCursor, cursorErr := parseCursor(children.NextCursor)
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+
+		childCursor.Parent = cursor.Parent
+		children.NextCursor = encodeCursor(childCursor)
+	case parents.NextCursor != "":
+		children.NextCursor = encodeCursor(&pageCursor{Parent: parents.NextCursor})
+	}
+
+	children.HasMore = children.NextCursor != ""
+
+	return children, nil
+}
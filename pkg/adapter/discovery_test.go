@@ -0,0 +1,114 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// teamMembersHandler returns a handler listing the teams and their members by
+// offset, one per page.
+func teamMembersHandler(t *testing.T, members map[string][]string) http.HandlerFunc {
+	teamIDs := []string{"T1", "T2"}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		switch {
+		case r.URL.Path == "/teams":
+			fmt.Fprintf(w, `{"teams":[{"id":%q}],"more":%t,"limit":1,"offset":%d}`,
+				teamIDs[offset], offset+1 < len(teamIDs), offset)
+		case strings.HasSuffix(r.URL.Path, "/members"):
+			userIDs := members[strings.Split(r.URL.Path, "/")[2]]
+
+			fmt.Fprintf(w, `{"members":[{"user":{"id":%q},"role":"responder"}],"more":%t,"limit":1,"offset":%d}`,
+				userIDs[offset], offset+1 < len(userIDs), offset)
+		default:
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+	}
+}
+
+func TestGetPageChildrenResumeMidParent(t *testing.T) {
+	server := newTestServer(t, teamMembersHandler(t, map[string][]string{
+		"T1": {"U1"},
+		"T2": {"U2", "U3"},
+	}))
+
+	// The cursor points into the second page of the members of the second
+	// team, i.e. of the team at offset 1.
+	request := newTestRequest(server, TeamMembers)
+	request.PageSize = 1
+	request.Cursor = encodeCursor(&pageCursor{Offset: 1, Parent: "1"})
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The last member of the last team is returned, without more pages.
+	AssertDeepEqual(t, []map[string]any{
+		{"id": "T2" + CompositeIDSeparator + "U3", "user": map[string]any{"id": "U3"}, "role": "responder"},
+	}, response.Objects)
+	AssertDeepEqual(t, "", response.NextCursor)
+}
+
+func TestGetPageChildrenCursors(t *testing.T) {
+	server := newTestServer(t, teamMembersHandler(t, map[string][]string{
+		"T1": {"U1"},
+		"T2": {"U2", "U3"},
+	}))
+
+	request := newTestRequest(server, TeamMembers)
+	request.PageSize = 1
+
+	client := NewClient(5)
+
+	// Paging moves to the next team after the last member of a team, and
+	// resumes within the members of a team.
+	var (
+		userIDs []string
+		cursors []string
+	)
+
+	for {
+		response, err := client.GetPage(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, member := range response.Objects {
+			userIDs = append(userIDs, member["user"].(map[string]any)["id"].(string))
+		}
+
+		if response.NextCursor == "" {
+			break
+		}
+
+		cursors = append(cursors, response.NextCursor)
+		request.Cursor = response.NextCursor
+	}
+
+	AssertDeepEqual(t, []string{"U1", "U2", "U3"}, userIDs)
+	AssertDeepEqual(t, []string{
+		encodeCursor(&pageCursor{Parent: "1"}),
+		encodeCursor(&pageCursor{Offset: 1, Parent: "1"}),
+	}, cursors)
+}
@@ -0,0 +1,283 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"syscall"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/sgnl-ai/adapter-framework/web"
+)
+
+const (
+	// maxErrorBodySize is the maximum number of bytes read from the body of an
+	// unsuccessful response.
+	maxErrorBodySize = 64 * 1024
+
+	// cursorExpiredMessage is the message of the error returned when the
+	// datasource rejects the request cursor.
+	cursorExpiredMessage = "Request cursor is invalid or expired. Restart paging from the first page."
+
+	// connectionRefusedMessage is the message of the error returned when the
+	// datasource refused the connection, i.e. before the request was sent.
+	connectionRefusedMessage = "Datasource refused the connection."
+
+	// timeoutMessage is the message of the error returned when the response
+	// headers were not received before the request timed out.
+	timeoutMessage = "Request to datasource timed out. Increase the request timeout and try again."
+
+	// notFoundMessagePrefix is the prefix of the message of the error returned
+	// when a requested object doesn't exist.
+	notFoundMessagePrefix = "Requested object was not found"
+
+	// invalidParamsErrorCode is the PagerDuty error code returned when the
+	// request parameters are invalid.
+	invalidParamsErrorCode = 2001
+
+	// insufficientScopeErrorCode is the PagerDuty error code returned when the
+	// token lacks the scope required to access a resource.
+	insufficientScopeErrorCode = 2010
+)
+
+// errorResponseBody is the body of an unsuccessful datasource response, e.g.
+// {"error": {"message": "Invalid Input Provided", "code": 2001, "errors": ["Offset must be..."]}}.
+type errorResponseBody struct {
+	Error struct {
+		Message string   `json:"message"`
+		Code    int      `json:"code"`
+		Errors  []string `json:"errors"`
+	} `json:"error"`
+}
+
+// parseErrorBody parses the body of an unsuccessful datasource response.
+// Returns false if the body doesn't contain an error description.
+func parseErrorBody(body []byte) (*errorResponseBody, bool) {
+	var errorBody errorResponseBody
+
+	if err := json.Unmarshal(body, &errorBody); err != nil || errorBody.Error.Message == "" {
+		return nil, false
+	}
+
+	return &errorBody, true
+}
+
+// message returns the error message, followed by the detailed errors if any.
+func (b *errorResponseBody) message() string {
+	if len(b.Error.Errors) == 0 {
+		return b.Error.Message
+	}
+
+	return fmt.Sprintf("%s: %s", b.Error.Message, strings.Join(b.Error.Errors, "; "))
+}
+
+// isInsufficientScope returns whether the response is a 403 caused by the
+// token lacking the scope required to access the entity, as opposed to other
+// access restrictions, as indicated by the PagerDuty error code.
+func isInsufficientScope(response *Response) bool {
+	return response.StatusCode == http.StatusForbidden && response.ErrorCode == insufficientScopeErrorCode
+}
+
+// isInsufficientScopeError returns whether the error was returned by
+// responseError for a response for which isInsufficientScope is true.
+func isInsufficientScopeError(err *framework.Error) bool {
+	return err != nil &&
+		err.Code == api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH &&
+		strings.Contains(err.Message, fmt.Sprintf(" Datasource error %d: ", insufficientScopeErrorCode))
+}
+
+// isInvalidCursorError returns whether the body of a 400 response indicates that
+// the offset or cursor of the request was rejected.
+func isInvalidCursorError(body []byte) bool {
+	errorBody, ok := parseErrorBody(body)
+	if !ok {
+		return false
+	}
+
+	for _, message := range append(errorBody.Error.Errors, errorBody.Error.Message) {
+		message = strings.ToLower(message)
+
+		if strings.Contains(message, "cursor") || strings.Contains(message, "offset") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cursorExpiredError() *framework.Error {
+	return &framework.Error{
+		Message: cursorExpiredMessage,
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+	}
+}
+
+// IsCursorExpired returns whether the error indicates that the datasource
+// rejected the request cursor, in which case paging should be restarted from
+// the first page rather than treating the error as fatal.
+func IsCursorExpired(err *framework.Error) bool {
+	return err != nil &&
+		err.Code == api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG &&
+		strings.HasPrefix(err.Message, cursorExpiredMessage)
+}
+
+func notFoundError(entityExternalID, id string) *framework.Error {
+	return &framework.Error{
+		Message: fmt.Sprintf("%s: %s %s.", notFoundMessagePrefix, entityExternalID, id),
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+	}
+}
+
+// IsNotFound returns whether the error indicates that the requested object
+// doesn't exist in the datasource.
+func IsNotFound(err *framework.Error) bool {
+	return err != nil && strings.HasPrefix(err.Message, notFoundMessagePrefix)
+}
+
+// truncatedBodyError returns the error for a response body that could not be
+// read completely, e.g. a chunked or gzip stream truncated mid-way. Such errors
+// are transient and retryable.
+func truncatedBodyError(err error) *framework.Error {
+	return &framework.Error{
+		Message: fmt.Sprintf("Failed to read response body: %v.", err),
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+	}
+}
+
+// sendError returns the error for a request that failed to be sent or whose
+// response headers were not received.
+func sendError(err error) *framework.Error {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return connectionRefusedError()
+	case errors.Is(err, context.DeadlineExceeded):
+		return &framework.Error{
+			Message: timeoutMessage,
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+		}
+	default:
+		return &framework.Error{
+			Message: "Failed to send request to datasource.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+		}
+	}
+}
+
+// connectionRefusedError returns the error for a request that could not be
+// sent because the datasource refused the connection.
+func connectionRefusedError() *framework.Error {
+	return &framework.Error{
+		Message: connectionRefusedMessage,
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+	}
+}
+
+// isConnectionRefused returns whether the error was returned for a request
+// that never reached the datasource.
+func isConnectionRefused(err *framework.Error) bool {
+	return err != nil && err.Message == connectionRefusedMessage
+}
+
+// isTimeout returns whether the error was returned for a request that timed
+// out before the response headers were received.
+func isTimeout(err *framework.Error) bool {
+	return err != nil && err.Message == timeoutMessage
+}
+
+// canceledError returns the error for an operation interrupted by the
+// cancellation of its context.
+func canceledError(err error) *framework.Error {
+	return &framework.Error{
+		Message: fmt.Sprintf("Request to datasource was interrupted: %v.", err),
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+	}
+}
+
+// isWaitPastDeadline returns whether the error was returned for an operation
+// interrupted because its context's deadline was before the end of a wait,
+// e.g. between two pages.
+func isWaitPastDeadline(err *framework.Error) bool {
+	return err != nil && err.Message == canceledError(errWaitPastDeadline).Message
+}
+
+// offsetCapError returns the error for a page whose next offset exceeds the
+// maximum offset accepted by PagerDuty.
+func offsetCapError() *framework.Error {
+	return &framework.Error{
+		Message: fmt.Sprintf("Datasource cannot return objects beyond offset %d. Narrow the request, e.g. its since/until time window.", maxOffset),
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+	}
+}
+
+// responseError returns the error for an unsuccessful response of the
+// datasource, with an error code specific to the status code and the message of
+// the datasource, if any, e.g. the invalid parameters of a 400.
+// The PagerDuty error code of the response, if any, takes precedence over the
+// status code, e.g. an insufficient scope is an authentication error.
+// Rate-limited responses keep the error code and Retry-After recommendation
+// of httpError, so that callers can retry them.
+// Returns nil if the response is successful.
+func responseError(response *Response) *framework.Error {
+	err := web.HTTPError(response.StatusCode, response.RetryAfterHeader)
+	if err == nil {
+		return nil
+	}
+
+	switch statusCode := response.StatusCode; {
+	case statusCode == http.StatusTooManyRequests:
+	case response.ErrorCode == invalidParamsErrorCode:
+		err.Code = api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG
+	case response.ErrorCode == insufficientScopeErrorCode:
+		err.Code = api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		err.Code = api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH
+	case statusCode >= 400 && statusCode < 500:
+		err.Code = api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG
+	case statusCode == http.StatusBadGateway || statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout:
+		// Gateway errors are transient and keep their error code.
+	case statusCode >= 500:
+		err.Code = api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED
+	}
+
+	switch {
+	case response.ErrorMessage != "" && response.ErrorCode != 0:
+		err.Message = fmt.Sprintf("%s Datasource error %d: %s.", err.Message, response.ErrorCode,
+			strings.TrimSuffix(response.ErrorMessage, "."))
+	case response.ErrorMessage != "":
+		err.Message = fmt.Sprintf("%s Datasource error: %s.", err.Message, strings.TrimSuffix(response.ErrorMessage, "."))
+	}
+
+	return err
+}
+
+// HTTPStatus returns the unsuccessful HTTP status code returned by the
+// datasource for the response, e.g. to distinguish a 403 from a 404.
+// GetPage returns the response together with the errors caused by a status
+// code, e.g. a not found parent object or an expired cursor.
+// Returns false if the response is nil or successful.
+func HTTPStatus(response *Response) (int, bool) {
+	if response == nil || response.StatusCode >= 200 && response.StatusCode < 300 {
+		return 0, false
+	}
+
+	return response.StatusCode, true
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// metricsRecorder records the metrics of the pages.
+type metricsRecorder struct {
+	pages []*PageMetrics
+}
+
+func (r *metricsRecorder) ObservePage(metrics *PageMetrics) {
+	r.pages = append(r.pages, metrics)
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := map[string]struct {
+		response       *Response
+		wantStatusCode int
+		wantFound      bool
+	}{
+		"forbidden": {
+			response:       &Response{StatusCode: http.StatusForbidden},
+			wantStatusCode: http.StatusForbidden,
+			wantFound:      true,
+		},
+		"not_found": {
+			response:       &Response{StatusCode: http.StatusNotFound},
+			wantStatusCode: http.StatusNotFound,
+			wantFound:      true,
+		},
+		"successful": {
+			response: &Response{StatusCode: http.StatusOK},
+		},
+		"nil": {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			statusCode, found := HTTPStatus(tt.response)
+
+			AssertDeepEqual(t, tt.wantStatusCode, statusCode)
+			AssertDeepEqual(t, tt.wantFound, found)
+		})
+	}
+}
+
+func TestGetPageHTTPStatusErrors(t *testing.T) {
+	tests := map[string]struct {
+		entity         string
+		parentID       string
+		cursor         string
+		statusCode     int
+		body           string
+		wantErr        *framework.Error
+		wantStatusCode int
+	}{
+		"parent_not_found": {
+			entity:         IncidentAlerts,
+			parentID:       "Q1",
+			statusCode:     http.StatusNotFound,
+			body:           `{"error":{"message":"Not Found","code":2100}}`,
+			wantErr:        notFoundError(IncidentAlerts+" parent", "Q1"),
+			wantStatusCode: http.StatusNotFound,
+		},
+		"cursor_expired": {
+			entity:         Users,
+			cursor:         "100",
+			statusCode:     http.StatusBadRequest,
+			body:           `{"error":{"message":"Invalid Input Provided","code":2001,"errors":["Offset must be less than 100."]}}`,
+			wantErr:        cursorExpiredError(),
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			})
+
+			metrics := &metricsRecorder{}
+
+			request := newTestRequest(server, tt.entity)
+			request.ParentID = tt.parentID
+			request.Cursor = tt.cursor
+
+			response, err := NewClient(5, WithMetrics(metrics)).GetPage(context.Background(), request)
+
+			AssertDeepEqual(t, tt.wantErr, err)
+
+			statusCode, found := HTTPStatus(response)
+
+			AssertDeepEqual(t, tt.wantStatusCode, statusCode)
+			AssertDeepEqual(t, true, found)
+			AssertDeepEqual(t, tt.wantStatusCode, metrics.pages[0].StatusCode)
+		})
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	err := notFoundError(Users, "U1")
+
+	AssertDeepEqual(t, notFoundMessagePrefix+": users U1.", err.Message)
+	AssertDeepEqual(t, true, IsNotFound(err))
+	AssertDeepEqual(t, cursorExpiredMessage, cursorExpiredError().Message)
+	AssertDeepEqual(t, true, IsCursorExpired(cursorExpiredError()))
+}
+
+func TestResponseError(t *testing.T) {
+	retryAfter := 30 * time.Second
+
+	tests := map[string]struct {
+		response *Response
+		wantErr  *framework.Error
+	}{
+		"ok": {
+			response: &Response{StatusCode: http.StatusOK},
+		},
+		"bad_request": {
+			response: &Response{StatusCode: http.StatusBadRequest},
+			wantErr: &framework.Error{
+				Message: "Datasource rejected request, returned status code: 400.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+		"bad_request_with_body": {
+			response: &Response{StatusCode: http.StatusBadRequest, ErrorCode: invalidParamsErrorCode, ErrorMessage: "Invalid Input Provided."},
+			wantErr: &framework.Error{
+				Message: "Datasource rejected request, returned status code: 400. Datasource error 2001: Invalid Input Provided.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+		"unauthorized": {
+			response: &Response{StatusCode: http.StatusUnauthorized},
+			wantErr: &framework.Error{
+				Message: "Failed to authenticate with datasource. Check datasource configuration details and try again.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+			},
+		},
+		"forbidden_with_message": {
+			response: &Response{StatusCode: http.StatusForbidden, ErrorMessage: "Access Denied"},
+			wantErr: &framework.Error{
+				Message: "Access forbidden by datasource. Check datasource configuration details and try again. Datasource error: Access Denied.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+			},
+		},
+		"insufficient_scope": {
+			response: &Response{StatusCode: http.StatusForbidden, ErrorCode: insufficientScopeErrorCode, ErrorMessage: "Insufficient scope"},
+			wantErr: &framework.Error{
+				Message: "Access forbidden by datasource. Check datasource configuration details and try again. Datasource error 2010: Insufficient scope.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+			},
+		},
+		"not_found": {
+			response: &Response{StatusCode: http.StatusNotFound},
+			wantErr: &framework.Error{
+				Message: "Datasource rejected request, returned status code: 404.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+		"too_many_requests": {
+			response: &Response{StatusCode: http.StatusTooManyRequests, RetryAfterHeader: "30", ErrorCode: invalidParamsErrorCode},
+			wantErr: &framework.Error{
+				Message:    "Datasource received too many requests. Adjust datasource sync frequency and try again.",
+				Code:       api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TOO_MANY_REQUESTS,
+				RetryAfter: &retryAfter,
+			},
+		},
+		"internal_server_error": {
+			response: &Response{StatusCode: http.StatusInternalServerError},
+			wantErr: &framework.Error{
+				Message: "Datasource encountered an internal error. Contact datasource support for assistance.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			},
+		},
+		"not_implemented": {
+			response: &Response{StatusCode: http.StatusNotImplemented},
+			wantErr: &framework.Error{
+				Message: "Datasource is permanently unavailable. Contact datasource support for assistance.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			},
+		},
+		"service_unavailable": {
+			response: &Response{StatusCode: http.StatusServiceUnavailable},
+			wantErr: &framework.Error{
+				Message: "Datasource is temporarily unavailable; try again later, returned status code: 503.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			AssertDeepEqual(t, tt.wantErr, responseError(tt.response))
+		})
+	}
+}
+
+func TestGetPageConnectionRefused(t *testing.T) {
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {})
+	request := newTestRequest(server, Users)
+
+	server.Close()
+
+	_, err := NewClient(5).GetPage(context.Background(), request)
+
+	AssertDeepEqual(t, connectionRefusedError(), err)
+}
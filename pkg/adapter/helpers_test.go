@@ -0,0 +1,67 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func AssertDeepEqual(t *testing.T, want, got any) {
+	t.Helper()
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected %#v, got %#v", want, got)
+	}
+}
+
+// newTestServer returns a server responding with the handler, closed at the
+// end of the test.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestRequest returns a valid request for the entity to the server.
+func newTestRequest(server *httptest.Server, entityExternalID string) *Request {
+	return &Request{
+		BaseURL:           server.URL,
+		HTTPAuthorization: "Token token=test",
+		PageSize:          100,
+		EntityExternalID:  entityExternalID,
+	}
+}
+
+// newTLSTestServer returns a TLS server responding with the handler, closed at
+// the end of the test, and a Client trusting its certificate, e.g. for
+// requests through the Adapter, which only sends HTTPS requests.
+func newTLSTestServer(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) (*httptest.Server, Client) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	return server, NewClient(5, append(opts, WithRootCAs(pool))...)
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// GetIncidentsByKey returns the incidents with the given de-duplication key,
+// using the server-side `incident_key` filter of the incidents endpoint.
+//
+// A key may be shared by multiple incidents over time, e.g. when an alert storm
+// re-triggers after the previous incident was resolved, so all matching
+// incidents are returned, oldest first. Unless the request specifies a time
+// window, incidents are searched over all time rather than PagerDuty's default
+// window.
+func (d *Datasource) GetIncidentsByKey(
+	ctx context.Context, request *Request, incidentKey string,
+) ([]map[string]any, *framework.Error) {
+	incidentsRequest := *request
+	incidentsRequest.EntityExternalID = Incidents
+	incidentsRequest.Attributes = nil
+	incidentsRequest.Cursor = ""
+	incidentsRequest.QueryParams = withQueryParam(request.QueryParams, "incident_key", incidentKey)
+
+	if _, found := incidentsRequest.QueryParams["since"]; !found && (request.Options == nil || request.Options.Since.IsZero()) {
+		incidentsRequest.QueryParams["date_range"] = []string{"all"}
+	}
+
+	incidents, err := d.GetAllPages(ctx, &incidentsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC3339 timestamps in the same time zone sort chronologically.
+	slices.SortStableFunc(incidents.Objects, func(a, b map[string]any) int {
+		createdA, _ := a["created_at"].(string)
+		createdB, _ := b["created_at"].(string)
+
+		return strings.Compare(createdA, createdB)
+	})
+
+	return incidents.Objects, nil
+}
+
+// windowCursor returns the cursor of the next page of an entity whose time
+// window is sliced past the offset cap, given the next cursor returned by
+// ParseResponse: a cursor starting a new time window is only valid if the
+// objects are sorted by the window attribute and the window moved forward,
+// and the other cursors continue in the time window of the current cursor.
+//
+// The objects sharing the datetime at the boundary of two windows are only
+// returned in the first window, see pageCursor.Boundary.
+func windowCursor(entity Entity, query url.Values, cursor *pageCursor, nextCursor string) (string, *framework.Error) {
+	if nextCursor == "" {
+		return "", nil
+	}
+
+	next, err := parseCursor(nextCursor)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case next.Since == "" && cursor.Since == "":
+		return nextCursor, nil
+	case next.Since == "":
+		next.Since = cursor.Since
+		next.Boundary = cursor.Boundary
+	case query.Get("sort_by") != entity.windowSort || next.Since == query.Get("since"):
+		// The window can't move forward if all the objects up to the offset
+		// cap share the same datetime.
+		return "", offsetCapError()
+	}
+
+	return encodeCursor(next), nil
+}
+
+// boundaryIDs returns the comma-separated unique IDs of the objects whose
+// window attribute is the since of the next time window.
+func boundaryIDs(objects []map[string]any, entity Entity, since string) string {
+	boundary, _ := time.Parse(time.RFC3339Nano, since)
+
+	var ids []string
+
+	for _, object := range objects {
+		value, _ := object[entity.highWaterMarkAttr].(string)
+
+		if datetime, err := time.Parse(time.RFC3339Nano, value); err == nil && datetime.Equal(boundary) {
+			if id, ok := object[entity.uniqueIDAttrExternalID].(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return strings.Join(ids, ",")
+}
+
+// withoutBoundary returns the objects without those listed in the boundary of
+// the cursor's time window.
+func withoutBoundary(objects []map[string]any, entity Entity, cursor *pageCursor) []map[string]any {
+	boundary := strings.Split(cursor.Boundary, ",")
+
+	return slices.DeleteFunc(objects, func(object map[string]any) bool {
+		id, _ := object[entity.uniqueIDAttrExternalID].(string)
+
+		return slices.Contains(boundary, id)
+	})
+}
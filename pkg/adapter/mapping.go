@@ -0,0 +1,199 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// ResponseMapping locates the objects and paging fields of the responses of
+// an endpoint with JSON path expressions, e.g. `$.data.items`, for endpoints
+// whose responses are not shaped like those of the PagerDuty REST API.
+// Only the dot-separated child operator and array indices are supported, e.g.
+// `$.results[0].items`.
+type ResponseMapping struct {
+	// Path is the endpoint path, relative to the BaseURL, of an entity that is
+	// not in ValidEntityExternalIDs.
+	// Optional. Ignored for entities in ValidEntityExternalIDs.
+	Path string
+
+	// UniqueIDAttribute is the unique ID attribute of an entity that is not in
+	// ValidEntityExternalIDs.
+	// Optional. Defaults to "id".
+	UniqueIDAttribute string
+
+	// ListPath is the path of the list of objects.
+	ListPath string
+
+	// MorePath is the path of the boolean indicating that more pages follow,
+	// which are then requested by offset.
+	// Optional. If empty and NextCursorPath is empty, each response is the
+	// last page.
+	MorePath string
+
+	// NextCursorPath is the path of the cursor of the next page, which is then
+	// requested with the `cursor` parameter.
+	// Optional. Takes precedence over MorePath when set in a response.
+	NextCursorPath string
+
+	// TotalPath is the path of the total number of objects, returned in
+	// Response.Total.
+	// Optional.
+	TotalPath string
+}
+
+// mappedEntity returns the entity of a request for an entity that is not in
+// ValidEntityExternalIDs, defined by its response mapping.
+// Returns false if the mapping doesn't define an endpoint path.
+func mappedEntity(mapping *ResponseMapping) (Entity, bool) {
+	if mapping == nil || mapping.Path == "" {
+		return Entity{}, false
+	}
+
+	uniqueIDAttr := mapping.UniqueIDAttribute
+	if uniqueIDAttr == "" {
+		uniqueIDAttr = "id"
+	}
+
+	return Entity{
+		uniqueIDAttrExternalID: uniqueIDAttr,
+		path:                   strings.Trim(mapping.Path, "/"),
+		mapping:                mapping,
+	}, true
+}
+
+// decodeMappedResponse decodes a datasource response body with the mapping.
+// Responses with a mapping don't return their offset, so the next offset is
+// computed from the offset of the request and the number of objects returned.
+// Returns false if the body has no list of objects at the mapping's list path.
+func decodeMappedResponse(body io.Reader, mapping *ResponseMapping, offset int64) (*DatasourceResponse, bool, *framework.Error) {
+	var document any
+
+	if err := json.NewDecoder(body).Decode(&document); err != nil {
+		return nil, false, decodeError(err)
+	}
+
+	list, found := jsonPathValue(document, mapping.ListPath)
+	if !found {
+		return nil, false, nil
+	}
+
+	items, ok := list.([]any)
+	if !ok && list != nil {
+		return nil, false, mappingError(mapping.ListPath, "a list of objects")
+	}
+
+	data := &DatasourceResponse{
+		Objects: make([]map[string]any, 0, len(items)),
+		Offset:  offset,
+		Limit:   int64(len(items)),
+	}
+
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			return nil, false, mappingError(mapping.ListPath, "a list of objects")
+		}
+
+		data.Objects = append(data.Objects, object)
+	}
+
+	if value, found := jsonPathValue(document, mapping.MorePath); found && value != nil {
+		if data.More, ok = value.(bool); !ok {
+			return nil, false, mappingError(mapping.MorePath, "a boolean")
+		}
+	}
+
+	if value, found := jsonPathValue(document, mapping.NextCursorPath); found && value != nil {
+		if data.NextCursor, ok = value.(string); !ok {
+			return nil, false, mappingError(mapping.NextCursorPath, "a string")
+		}
+	}
+
+	if value, found := jsonPathValue(document, mapping.TotalPath); found && value != nil {
+		total, ok := value.(float64)
+		if !ok {
+			return nil, false, mappingError(mapping.TotalPath, "a number")
+		}
+
+		data.Total = int64(total)
+	}
+
+	return data, true, nil
+}
+
+func mappingError(path, expected string) *framework.Error {
+	return &framework.Error{
+		Message: fmt.Sprintf("Datasource response value at %s is not %s.", path, expected),
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+	}
+}
+
+// jsonPathValue returns the value at the JSON path in the document, e.g.
+// `$.data.items` or `$.results[0].items`.
+// Returns false if the path is empty or the value doesn't exist.
+func jsonPathValue(document any, path string) (any, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	value := document
+
+	segments := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, "$"), "."), ".")
+	if path == "$" {
+		segments = nil
+	}
+
+	for _, segment := range segments {
+		key, indices, _ := strings.Cut(segment, "[")
+
+		if key != "" {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+
+			if value, ok = object[key]; !ok {
+				return nil, false
+			}
+		}
+
+		for indices != "" {
+			rawIndex, rest, found := strings.Cut(indices, "]")
+			if !found {
+				return nil, false
+			}
+
+			index, err := strconv.Atoi(rawIndex)
+			list, ok := value.([]any)
+
+			if err != nil || !ok || index < 0 || index >= len(list) {
+				return nil, false
+			}
+
+			value = list[index]
+			indices = strings.TrimPrefix(rest, "[")
+		}
+	}
+
+	return value, true
+}
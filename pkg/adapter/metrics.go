@@ -0,0 +1,119 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// PageMetrics describes a GetPage call.
+type PageMetrics struct {
+	// EntityExternalID is the external ID of the requested entity.
+	EntityExternalID string
+
+	// Cursor is the cursor of the requested page. Empty for the first page.
+	Cursor string
+
+	// Duration is the duration of the call, including retries.
+	Duration time.Duration
+
+	// StatusCode is the HTTP status code of the last response, or zero if no
+	// response was received.
+	StatusCode int
+
+	// Objects is the number of objects returned.
+	Objects int
+
+	// Retries is the number of times the request was retried.
+	Retries int
+
+	// RequestID is the X-Request-Id header of the last response, identifying
+	// the request for PagerDuty support.
+	RequestID string
+
+	// Err is the error returned, if any.
+	Err *framework.Error
+}
+
+// Metrics records the metrics of the GetPage calls of a Datasource, e.g. as
+// Prometheus histograms and counters labeled by entity and status code.
+// ObservePage may be called concurrently.
+type Metrics interface {
+	ObservePage(metrics *PageMetrics)
+}
+
+// WithMetrics records the metrics of each GetPage call with metrics.
+func WithMetrics(metrics Metrics) ClientOption {
+	return func(d *Datasource) {
+		d.Metrics = metrics
+	}
+}
+
+// WithRequestLogging logs the metrics of each GetPage call as a structured
+// line of key=value pairs, e.g.
+// "page entity=users cursor=100 status=200 objects=100 retries=0 duration=312ms request_id=...",
+// and the saturation of the rate limiter when requests wait for it.
+func WithRequestLogging() ClientOption {
+	return func(d *Datasource) {
+		d.LogRequests = true
+	}
+}
+
+// instrument calls getPage and records its metrics.
+func (d *Datasource) instrument(
+	ctx context.Context, request *Request, getPage func(context.Context, *Request) (*Response, *framework.Error),
+) (*Response, *framework.Error) {
+	if d.Metrics == nil && !d.LogRequests {
+		return getPage(ctx, request)
+	}
+
+	start := time.Now()
+
+	response, err := getPage(ctx, request)
+
+	metrics := &PageMetrics{
+		EntityExternalID: request.EntityExternalID,
+		Cursor:           request.Cursor,
+		Duration:         time.Since(start),
+		Err:              err,
+	}
+
+	if response != nil {
+		metrics.StatusCode = response.StatusCode
+		metrics.Objects = len(response.Objects)
+		metrics.Retries = response.Retries
+		metrics.RequestID = response.RequestID
+	}
+
+	if d.Metrics != nil {
+		d.Metrics.ObservePage(metrics)
+	}
+
+	if d.LogRequests {
+		errMessage := ""
+		if err != nil {
+			errMessage = err.Message
+		}
+
+		d.logf("page entity=%s cursor=%q status=%d objects=%d retries=%d duration=%s request_id=%q error=%q",
+			metrics.EntityExternalID, metrics.Cursor, metrics.StatusCode, metrics.Objects, metrics.Retries,
+			metrics.Duration.Round(time.Millisecond), metrics.RequestID, errMessage)
+	}
+
+	return response, err
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultOAuthTokenURL is the endpoint of the PagerDuty identity service
+	// issuing access tokens.
+	defaultOAuthTokenURL = "https://identity.pagerduty.com/oauth/token"
+
+	// defaultTokenExpiryMargin is how long before its expiry an access token
+	// is refreshed, so that it doesn't expire while a request is in flight.
+	defaultTokenExpiryMargin = time.Minute
+)
+
+// ClientCredentialsProvider is a TokenProvider obtaining OAuth access tokens
+// for a PagerDuty app with the client credentials grant, e.g. for scoped app
+// tokens. Tokens are cached and refreshed before they expire.
+// It is safe for concurrent use.
+type ClientCredentialsProvider struct {
+	// TokenURL is the token endpoint.
+	// Optional. Defaults to the PagerDuty identity service.
+	TokenURL string
+
+	// ClientID and ClientSecret are the credentials of the app.
+	ClientID     string
+	ClientSecret string
+
+	// Scopes is the list of scopes requested, e.g.
+	// "as_account-us.example" and "users.read".
+	Scopes []string
+
+	// ExpiryMargin is how long before its expiry an access token is
+	// refreshed.
+	// Optional. Defaults to 1 minute.
+	ExpiryMargin time.Duration
+
+	// Client is the HTTP client used to request tokens.
+	// Optional. Defaults to a client with a 10 second timeout.
+	Client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// tokenResponseBody is the body of a successful response of the token
+// endpoint.
+type tokenResponseBody struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+
+	// ExpiresIn is the lifetime of the access token, in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// Token returns the cached access token, requesting a new one if none was
+// requested yet or if it is about to expire.
+func (p *ClientCredentialsProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	margin := p.ExpiryMargin
+	if margin <= 0 {
+		margin = defaultTokenExpiryMargin
+	}
+
+	if p.token != "" && time.Now().Add(margin).Before(p.expiry) {
+		return p.token, nil
+	}
+
+	return p.requestToken(ctx)
+}
+
+// Refresh requests a new access token, e.g. after the cached one was revoked.
+func (p *ClientCredentialsProvider) Refresh(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.requestToken(ctx)
+}
+
+// requestToken requests a new access token and caches it.
+// Must be called with mu held.
+func (p *ClientCredentialsProvider) requestToken(ctx context.Context) (string, error) {
+	tokenURL := p.TokenURL
+	if tokenURL == "" {
+		tokenURL = defaultOAuthTokenURL
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+
+	if len(p.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status code %d", res.StatusCode)
+	}
+
+	var token tokenResponseBody
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", errors.New("token response contains no access token")
+	}
+
+	p.token = bearerPrefix + token.AccessToken
+	p.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return p.token, nil
+}
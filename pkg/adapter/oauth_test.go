@@ -0,0 +1,258 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// tokenHandler returns a handler of the token endpoint issuing the tokens in
+// turn, the last one repeatedly, each valid for expiresIn seconds, and
+// counting the token requests.
+func tokenHandler(t *testing.T, requests *atomic.Int32, expiresIn int, tokens ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse the token request: %v", err)
+		}
+
+		AssertDeepEqual(t, "client_credentials", r.PostForm.Get("grant_type"))
+		AssertDeepEqual(t, "client", r.PostForm.Get("client_id"))
+		AssertDeepEqual(t, "secret", r.PostForm.Get("client_secret"))
+		AssertDeepEqual(t, "users.read teams.read", r.PostForm.Get("scope"))
+
+		token := tokens[min(n, len(tokens))-1]
+
+		w.Write([]byte(`{"access_token":"` + token + `","token_type":"bearer","expires_in":` + strconv.Itoa(expiresIn) + `}`))
+	}
+}
+
+func newTestProvider(tokenURL string) *ClientCredentialsProvider {
+	return &ClientCredentialsProvider{
+		TokenURL:     tokenURL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"users.read", "teams.read"},
+	}
+}
+
+func TestClientCredentialsProviderToken(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, tokenHandler(t, &requests, 3600, "first", "second"))
+	provider := newTestProvider(server.URL)
+
+	token, err := provider.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, "Bearer first", token)
+
+	// The token is cached until it is about to expire.
+	token, err = provider.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, "Bearer first", token)
+	AssertDeepEqual(t, int32(1), requests.Load())
+
+	token, err = provider.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, "Bearer second", token)
+	AssertDeepEqual(t, int32(2), requests.Load())
+}
+
+func TestClientCredentialsProviderRefreshBeforeExpiry(t *testing.T) {
+	var requests atomic.Int32
+
+	// Tokens expire in 30 seconds, within the default 1 minute margin.
+	server := newTestServer(t, tokenHandler(t, &requests, 30, "first", "second"))
+	provider := newTestProvider(server.URL)
+
+	if _, err := provider.Token(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := provider.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, "Bearer second", token)
+	AssertDeepEqual(t, int32(2), requests.Load())
+}
+
+func TestClientCredentialsProviderError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	if _, err := newTestProvider(server.URL).Token(context.Background()); err == nil {
+		t.Error("Expected an error for a rejected token request")
+	}
+}
+
+func TestGetPageTokenProviderRetriesUnauthorizedOnce(t *testing.T) {
+	tests := map[string]struct {
+		validTokens      map[string]bool
+		wantStatusCode   int
+		wantAuthAttempts int32
+	}{
+		"refreshed_token_accepted": {
+			validTokens:      map[string]bool{"Bearer second": true},
+			wantStatusCode:   http.StatusOK,
+			wantAuthAttempts: 2,
+		},
+		"refreshed_token_rejected": {
+			validTokens:      map[string]bool{},
+			wantStatusCode:   http.StatusUnauthorized,
+			wantAuthAttempts: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tokenRequests, pageRequests atomic.Int32
+
+			handleToken := tokenHandler(t, &tokenRequests, 3600, "first", "second")
+
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/oauth/token" {
+					handleToken(w, r)
+
+					return
+				}
+
+				pageRequests.Add(1)
+
+				if !tt.validTokens[r.Header.Get("Authorization")] {
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+
+				w.Write([]byte(`{"users":[],"more":false,"limit":100,"offset":0}`))
+			})
+
+			request := newTestRequest(server, Users)
+			request.HTTPAuthorization = ""
+			request.TokenProvider = newTestProvider(server.URL + "/oauth/token")
+
+			response, err := NewClient(5).GetPage(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// The response to the retry is returned even if still rejected.
+			AssertDeepEqual(t, tt.wantStatusCode, response.StatusCode)
+
+			AssertDeepEqual(t, tt.wantAuthAttempts, pageRequests.Load())
+			AssertDeepEqual(t, int32(2), tokenRequests.Load())
+		})
+	}
+}
+
+func TestAdapterGetPageClientCredentials(t *testing.T) {
+	var tokenRequests atomic.Int32
+
+	handleToken := tokenHandler(t, &tokenRequests, 3600, "first")
+
+	server, client := newTLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			handleToken(w, r)
+
+			return
+		}
+
+		AssertDeepEqual(t, "Bearer first", r.Header.Get("Authorization"))
+
+		w.Write([]byte(`{"users":[{"id":"U1"}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	adapter := NewAdapter(client)
+
+	// No static token is required with client credentials.
+	request := &framework.Request[Config]{
+		Address: server.URL,
+		Config: &Config{
+			OAuthClientID:     "client",
+			OAuthClientSecret: "secret",
+			OAuthScopes:       []string{"users.read", "teams.read"},
+			OAuthTokenURL:     server.URL + "/oauth/token",
+		},
+		Entity: framework.EntityConfig{
+			ExternalId: Users,
+			Attributes: []*framework.AttributeConfig{
+				{ExternalId: "id", Type: framework.AttributeTypeString},
+			},
+		},
+		PageSize: 100,
+	}
+
+	for i := 0; i < 2; i++ {
+		response := adapter.GetPage(context.Background(), request)
+		if response.Error != nil {
+			t.Fatalf("Unexpected error: %v", response.Error)
+		}
+
+		AssertDeepEqual(t, []framework.Object{{"id": "U1"}}, response.Success.Objects)
+	}
+
+	// The provider and its token are reused across GetPage calls.
+	AssertDeepEqual(t, int32(1), tokenRequests.Load())
+}
+
+func TestConfigValidateClientCredentials(t *testing.T) {
+	tests := map[string]struct {
+		config  Config
+		wantErr bool
+	}{
+		"valid": {
+			config: Config{OAuthClientID: "client", OAuthClientSecret: "secret", OAuthTokenURL: "https://identity.example.com/oauth/token"},
+		},
+		"missing_secret": {
+			config:  Config{OAuthClientID: "client"},
+			wantErr: true,
+		},
+		"scopes_without_credentials": {
+			config:  Config{OAuthScopes: []string{"users.read"}},
+			wantErr: true,
+		},
+		"http_token_url": {
+			config:  Config{OAuthClientID: "client", OAuthClientSecret: "secret", OAuthTokenURL: "http://identity.example.com/oauth/token"},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.config.Validate(context.Background())
+
+			AssertDeepEqual(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// referenceCache caches single objects of entities whose data is static, e.g.
+// vendors, keyed by entity external ID and object ID.
+type referenceCache struct {
+	mu      sync.RWMutex
+	objects map[string]map[string]any
+
+	// compress indicates whether objects are stored as gzip-compressed JSON
+	// in compressed rather than in objects, trading CPU for memory.
+	compress   bool
+	compressed map[string][]byte
+}
+
+// WithCompressedCache stores the cached objects of cacheable entities
+// gzip-compressed in memory, and decompresses them on each access, to keep
+// larger caches within a memory budget.
+func WithCompressedCache() ClientOption {
+	return func(d *Datasource) {
+		d.references.compress = true
+	}
+}
+
+func (c *referenceCache) get(key string) (map[string]any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if object, found := c.objects[key]; found {
+		return object, true
+	}
+
+	data, found := c.compressed[key]
+	if !found {
+		return nil, false
+	}
+
+	// An object that can't be decompressed is fetched again.
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+
+	var object map[string]any
+
+	if err := json.NewDecoder(reader).Decode(&object); err != nil {
+		return nil, false
+	}
+
+	return object, true
+}
+
+func (c *referenceCache) set(key string, object map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.compress {
+		if data, err := compressObject(object); err == nil {
+			if c.compressed == nil {
+				c.compressed = make(map[string][]byte)
+			}
+
+			c.compressed[key] = data
+
+			return
+		}
+	}
+
+	if c.objects == nil {
+		c.objects = make(map[string]map[string]any)
+	}
+
+	c.objects[key] = object
+}
+
+// compressObject returns the gzip-compressed JSON encoding of the object.
+func compressObject(object map[string]any) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+
+	if err := json.NewEncoder(writer).Encode(object); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GetObject returns the object with the given ID of the requested entity,
+// e.g. a single vendor from `/vendors/{id}`.
+// Objects of cacheable entities are only fetched once per Datasource.
+// Returns a not-found error if the object doesn't exist.
+func (d *Datasource) GetObject(ctx context.Context, request *Request, id string) (map[string]any, *framework.Error) {
+	return d.getObject(ctx, request, id, nil)
+}
+
+// getObject returns the object with the given ID of the requested entity,
+// requested with the given query parameters, e.g. `include[]` expansions.
+// Objects requested with query parameters are not cached.
+func (d *Datasource) getObject(
+	ctx context.Context, request *Request, id string, query url.Values,
+) (map[string]any, *framework.Error) {
+	entity, found := ValidEntityExternalIDs[request.EntityExternalID]
+	if !found || entity.objectKey == "" {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Provided entity external ID does not support fetching single objects: %s.", request.EntityExternalID),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+		}
+	}
+
+	cacheKey := request.EntityExternalID + "/" + id
+	cacheable := entity.cacheable && len(query) == 0
+
+	if cacheable {
+		if object, found := d.references.get(cacheKey); found {
+			return deepCopy(object), nil
+		}
+	}
+
+	path, pathErr := entityPath(entity, request)
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	// The object is requested like a page of objects of an entity at its path.
+	objectEntity := Entity{path: path + "/" + url.PathEscape(id)}
+
+	requestURL, urlErr := pageURL(objectEntity, request, query)
+	if urlErr != nil {
+		return nil, urlErr
+	}
+
+	response, body, doErr := d.do(ctx, request, http.MethodGet, requestURL, nil)
+	if doErr != nil {
+		return nil, doErr
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, notFoundError(request.EntityExternalID, id)
+	}
+
+	if adapterErr := responseError(response); adapterErr != nil {
+		return nil, adapterErr
+	}
+
+	var envelope map[string]map[string]any
+
+	if unmarshalErr := json.Unmarshal(body, &envelope); unmarshalErr != nil {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Failed to unmarshal the datasource response: %v.", unmarshalErr),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+		}
+	}
+
+	object, found := envelope[entity.objectKey]
+	if !found {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Datasource response is missing the %s field.", entity.objectKey),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	}
+
+	if cacheable {
+		d.references.set(cacheKey, deepCopy(object))
+	}
+
+	return object, nil
+}
+
+// deepCopy returns a copy of the JSON object, including its nested objects and
+// lists, so that modifying the copy doesn't modify the original, e.g. a cached
+// object.
+func deepCopy(object map[string]any) map[string]any {
+	copied, _ := deepCopyValue(object).(map[string]any)
+
+	return copied
+}
+
+func deepCopyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+
+		for key, nested := range v {
+			copied[key] = deepCopyValue(nested)
+		}
+
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+
+		for i, nested := range v {
+			copied[i] = deepCopyValue(nested)
+		}
+
+		return copied
+	default:
+		return v
+	}
+}
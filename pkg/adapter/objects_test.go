@@ -0,0 +1,99 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetObjectBaseURLQuery(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		AssertDeepEqual(t, "/api/users/P%2F1", r.URL.EscapedPath())
+		AssertDeepEqual(t, "us", r.URL.Query().Get("region"))
+
+		w.Write([]byte(`{"user":{"id":"P/1"}}`))
+	})
+
+	// The query parameters of the base URL are preserved.
+	request := newTestRequest(server, Users)
+	request.BaseURL = server.URL + "/api/?region=us"
+
+	object, err := NewClient(5).(*Datasource).GetObject(context.Background(), request, "P/1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, map[string]any{"id": "P/1"}, object)
+}
+
+func TestGetObjectCachedCopy(t *testing.T) {
+	for name, opts := range map[string][]ClientOption{
+		"uncompressed": nil,
+		"compressed":   {WithCompressedCache()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+
+				w.Write([]byte(`{"vendor":{"id":"V1","thumbnail":{"url":"a"},"tags":["b"]}}`))
+			})
+
+			datasource := NewClient(5, opts...).(*Datasource)
+			request := newTestRequest(server, Vendors)
+
+			want := map[string]any{
+				"id":        "V1",
+				"thumbnail": map[string]any{"url": "a"},
+				"tags":      []any{"b"},
+			}
+
+			for i := 0; i < 3; i++ {
+				object, err := datasource.GetObject(context.Background(), request, "V1")
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				AssertDeepEqual(t, want, object)
+
+				// Modifying the nested values of a returned object doesn't
+				// modify the cached object.
+				object["thumbnail"].(map[string]any)["url"] = "modified"
+				object["tags"].([]any)[0] = "modified"
+			}
+
+			AssertDeepEqual(t, int32(1), requests.Load())
+		})
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	object := map[string]any{
+		"nested": map[string]any{"list": []any{map[string]any{"id": "1"}}},
+		"value":  float64(1),
+	}
+
+	copied := deepCopy(object)
+
+	AssertDeepEqual(t, object, copied)
+
+	copied["nested"].(map[string]any)["list"].([]any)[0].(map[string]any)["id"] = "2"
+
+	AssertDeepEqual(t, "1", object["nested"].(map[string]any)["list"].([]any)[0].(map[string]any)["id"])
+}
@@ -0,0 +1,44 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// GetUserOnCalls returns the current on-call entries of the user, one per
+// escalation policy level the user is on call for, either directly or through
+// a schedule.
+// Returns an empty list if the user is not on call.
+func (d *Datasource) GetUserOnCalls(ctx context.Context, request *Request, userID string) ([]map[string]any, *framework.Error) {
+	oncallsRequest := *request
+	oncallsRequest.EntityExternalID = Oncalls
+	oncallsRequest.Attributes = nil
+	oncallsRequest.Cursor = ""
+	oncallsRequest.QueryParams = withQueryParam(request.QueryParams, "user_ids[]", userID)
+
+	oncalls, err := d.GetAllPages(ctx, &oncallsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if oncalls.Objects == nil {
+		return []map[string]any{}, nil
+	}
+
+	return oncalls.Objects, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"maps"
+	"net/url"
+	"time"
+)
+
+// PageOptions contains the optional query options applied by GetPage when
+// requesting a page, in addition to the paging parameters.
+//
+// Query parameters are applied in the following order, later ones overriding
+// earlier ones with the same name:
+//  1. offset and limit,
+//  2. the entity's default query parameters,
+//  3. Includes, TeamIDs, Filters, SortBy, Since and Until,
+//  4. ExtraQuery,
+//  5. Request.QueryParams.
+type PageOptions struct {
+	// Includes is the list of related resources to embed in each object,
+	// sent as `include[]` parameters.
+	Includes []string
+
+	// TeamIDs restricts objects to those of the given teams, sent as
+	// `team_ids[]` parameters, e.g. for services or escalation policies.
+	TeamIDs []string
+
+	// Filters is the set of filter parameters, keyed by parameter name, e.g.
+	// `statuses[]`.
+	Filters map[string][]string
+
+	// SortBy is the sort order, sent as the `sort_by` parameter, e.g.
+	// `created_at:desc`.
+	SortBy string
+
+	// Since is the start of the time window, sent as the `since` parameter.
+	// Ignored if zero.
+	Since time.Time
+
+	// Until is the end of the time window, sent as the `until` parameter.
+	// Ignored if zero.
+	Until time.Time
+
+	// ExtraQuery is the set of additional query parameters, keyed by
+	// parameter name.
+	ExtraQuery map[string][]string
+}
+
+// query returns the query parameters for these options.
+func (o *PageOptions) query() url.Values {
+	query := url.Values{}
+
+	if o == nil {
+		return query
+	}
+
+	if len(o.Includes) > 0 {
+		query["include[]"] = o.Includes
+	}
+
+	if len(o.TeamIDs) > 0 {
+		query["team_ids[]"] = o.TeamIDs
+	}
+
+	for key, values := range o.Filters {
+		query[key] = values
+	}
+
+	if o.SortBy != "" {
+		query.Set("sort_by", o.SortBy)
+	}
+
+	if !o.Since.IsZero() {
+		query.Set("since", o.Since.UTC().Format(time.RFC3339))
+	}
+
+	if !o.Until.IsZero() {
+		query.Set("until", o.Until.UTC().Format(time.RFC3339))
+	}
+
+	for key, values := range o.ExtraQuery {
+		query[key] = values
+	}
+
+	return query
+}
+
+// withQueryParam returns a copy of the query parameters with the parameter set
+// to the values.
+func withQueryParam(params map[string][]string, key string, values ...string) map[string][]string {
+	params = maps.Clone(params)
+	if params == nil {
+		params = make(map[string][]string)
+	}
+
+	params[key] = values
+
+	return params
+}
@@ -0,0 +1,466 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// PagesOption configures how StreamPages and GetAllPages request pages.
+type PagesOption func(*pagesOptions)
+
+type pagesOptions struct {
+	// filters are the predicates an object must satisfy to be returned.
+	filters []func(object map[string]any) bool
+
+	// sortByUniqueID indicates whether GetAllPages sorts the objects by their
+	// unique ID.
+	sortByUniqueID bool
+
+	// deadline is the time after which GetAllPages stops requesting pages.
+	deadline time.Time
+
+	// initialCursor is the cursor of the first page to request, overriding
+	// the request's cursor. Set by WithInitialCursor.
+	initialCursor *string
+
+	// stop is the predicate matching the object after which paging stops.
+	stop func(object map[string]any) bool
+
+	// retryMode is how GetAllPages retries after a transient page failure.
+	retryMode RetryMode
+
+	// maxPageRetries is the maximum number of times GetAllPages retries after
+	// a transient page failure. If zero, failures are returned.
+	maxPageRetries int
+}
+
+// RetryMode is how GetAllPages resumes after a page failed with a transient
+// error, once the retries of the page request itself (see RetryPolicy) are
+// exhausted.
+type RetryMode int
+
+const (
+	// PageRetry requests the failed page again, keeping the objects of the
+	// previous pages. For volatile entities, objects created or deleted
+	// between the two requests shift the offsets, so objects may be returned
+	// twice or skipped.
+	PageRetry RetryMode = iota
+
+	// OperationRestart discards the objects of the previous pages and
+	// requests all the pages again from the initial cursor. The objects form a
+	// consistent listing, at the cost of requesting the previous pages again.
+	OperationRestart
+)
+
+func newPagesOptions(opts []PagesOption) *pagesOptions {
+	options := &pagesOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// ownsDeadline returns whether the deadline set with WithDeadline is the one
+// paging stops at, rather than the context's deadline.
+func (o *pagesOptions) ownsDeadline(ctx context.Context) bool {
+	if o.deadline.IsZero() {
+		return false
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	return !ok || o.deadline.Before(deadline)
+}
+
+// WithObjectFilter only returns objects for which the predicate returns true.
+// Filtering happens after each page is fetched, so it doesn't affect paging.
+func WithObjectFilter(predicate func(object map[string]any) bool) PagesOption {
+	return func(o *pagesOptions) {
+		o.filters = append(o.filters, predicate)
+	}
+}
+
+// hasRole returns a predicate matching objects whose `role` attribute is one of
+// the given roles.
+func hasRole(roles []string) func(object map[string]any) bool {
+	allowed := make(map[string]struct{}, len(roles))
+
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(object map[string]any) bool {
+		role, ok := object["role"].(string)
+		if !ok {
+			return false
+		}
+
+		_, found := allowed[role]
+
+		return found
+	}
+}
+
+// WithSortByUniqueID sorts the objects returned by GetAllPages by the entity's
+// unique ID attribute, so that the output is deterministic regardless of the
+// order in which the datasource returned them.
+// Objects without a unique ID are sorted last, and objects with the same ID
+// keep their relative order.
+func WithSortByUniqueID() PagesOption {
+	return func(o *pagesOptions) {
+		o.sortByUniqueID = true
+	}
+}
+
+// WithDeadline bounds the total time spent by GetAllPages requesting pages, in
+// addition to the context's deadline: paging stops at the deadline, and the
+// objects fetched so far are returned together with the cursor to resume
+// from, e.g. in the next scheduled sync.
+func WithDeadline(deadline time.Time) PagesOption {
+	return func(o *pagesOptions) {
+		o.deadline = deadline
+	}
+}
+
+// WithInitialCursor starts paging from the given cursor instead of the
+// request's cursor, e.g. the NextCursor of a previous PagesResult to resume a
+// sync interrupted by a deadline or an error.
+// The cursor may be an offset or an opaque cursor, depending on the entity.
+func WithInitialCursor(cursor string) PagesOption {
+	return func(o *pagesOptions) {
+		o.initialCursor = &cursor
+	}
+}
+
+// WithStopPredicate stops paging after the first object for which the
+// predicate returns true, e.g. the first object already ingested by a previous
+// incremental sync of an entity sorted newest first. That object is the last
+// one returned, and the page containing it is handled as the last page.
+// The predicate is called before objects are filtered.
+func WithStopPredicate(predicate func(object map[string]any) bool) PagesOption {
+	return func(o *pagesOptions) {
+		o.stop = predicate
+	}
+}
+
+// WithPageRetries makes GetAllPages retry at most maxRetries times in total
+// after a page failed with a transient error, e.g. the datasource being
+// temporarily unavailable or rate limiting requests, using the given mode.
+// Retries are spaced by the backoff of the request's or Datasource's
+// RetryPolicy.
+func WithPageRetries(mode RetryMode, maxRetries int) PagesOption {
+	return func(o *pagesOptions) {
+		o.retryMode = mode
+		o.maxPageRetries = maxRetries
+	}
+}
+
+// isTransient returns whether the error may not occur again if the request is
+// retried later.
+func isTransient(err *framework.Error) bool {
+	return err.Code == api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE ||
+		err.Code == api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TOO_MANY_REQUESTS
+}
+
+// StreamPages requests the pages of the requested entity, starting from the
+// request's cursor, until the last page. The handler is called with each page.
+// Stops at the first error returned by the datasource or the handler.
+// Consecutive page requests are spaced by at least the Datasource's
+// MinPageInterval.
+func (d *Datasource) StreamPages(
+	ctx context.Context, request *Request, handler func(response *Response) *framework.Error, opts ...PagesOption,
+) *framework.Error {
+	options := newPagesOptions(opts)
+
+	pageRequest := *request
+
+	if options.initialCursor != nil {
+		pageRequest.Cursor = *options.initialCursor
+	}
+
+	var lastFetch time.Time
+
+	for {
+		if !lastFetch.IsZero() && d.MinPageInterval > 0 {
+			if err := wait(ctx, d.MinPageInterval-time.Since(lastFetch)); err != nil {
+				return canceledError(err)
+			}
+		}
+
+		lastFetch = time.Now()
+
+		response, err := d.GetPage(ctx, &pageRequest)
+		if err != nil {
+			return err
+		}
+
+		if adapterErr := responseError(response); adapterErr != nil {
+			return adapterErr
+		}
+
+		if options.stop != nil {
+			if i := slices.IndexFunc(response.Objects, options.stop); i >= 0 {
+				response.Objects = response.Objects[:i+1]
+				response.NextCursor = ""
+			}
+		}
+
+		response.Objects = options.filter(response.Objects)
+
+		if err := handler(response); err != nil {
+			return err
+		}
+
+		if response.NextCursor == "" {
+			return nil
+		}
+
+		pageRequest.Cursor = response.NextCursor
+	}
+}
+
+// GetPageWithPeek returns the requested page, and whether it is the last page
+// of the entity according to the datasource's `more` flag.
+//
+// When last is true, the caller must not request the next page: no follow-up
+// request is needed to discover that the entity has no more objects. Paging
+// loops should therefore stop on last rather than on an empty page, which
+// avoids an extra request returning no objects. StreamPages and GetAllPages
+// stop the same way.
+func (d *Datasource) GetPageWithPeek(ctx context.Context, request *Request) (response *Response, last bool, err *framework.Error) {
+	response, err = d.GetPage(ctx, request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if adapterErr := responseError(response); adapterErr != nil {
+		return nil, false, adapterErr
+	}
+
+	return response, !response.HasMore, nil
+}
+
+// PagesResult is the result of GetAllPages.
+type PagesResult struct {
+	// Objects is the list of objects of the pages that were fetched.
+	Objects []map[string]any
+
+	// NextCursor is the cursor of the first page that was not fetched, from
+	// which paging can be resumed.
+	// Empty if all the pages were fetched.
+	NextCursor string
+
+	// DeadlineExceeded indicates whether paging stopped before the last page
+	// because the deadline set with WithDeadline was exceeded.
+	DeadlineExceeded bool
+}
+
+// GetAllPages returns the objects of all the pages of the requested entity,
+// starting from the request's cursor or the cursor set with WithInitialCursor.
+//
+// If a deadline is set with WithDeadline and exceeded before the last page,
+// the objects fetched so far are returned with the cursor to resume from,
+// rather than an error.
+// If a page request fails, the objects fetched so far and the cursor of the
+// failed page are returned together with the error, unless the failure is
+// transient and retried as set with WithPageRetries.
+func (d *Datasource) GetAllPages(
+	ctx context.Context, request *Request, opts ...PagesOption,
+) (*PagesResult, *framework.Error) {
+	options := newPagesOptions(opts)
+
+	pagesCtx := ctx
+
+	if !options.deadline.IsZero() {
+		var cancel context.CancelFunc
+
+		pagesCtx, cancel = context.WithDeadline(ctx, options.deadline)
+		defer cancel()
+	}
+
+	result := &PagesResult{}
+	initialCursor := request.Cursor
+
+	if options.initialCursor != nil {
+		initialCursor = *options.initialCursor
+	}
+
+	cursor := initialCursor
+
+	var (
+		err          *framework.Error
+		pastDeadline bool
+	)
+
+	for retries := 0; ; retries++ {
+		err = d.StreamPages(pagesCtx, request, func(response *Response) *framework.Error {
+			result.Objects = append(result.Objects, response.Objects...)
+			cursor = response.NextCursor
+
+			return nil
+		}, append(slices.Clone(opts), WithInitialCursor(cursor))...)
+
+		if err == nil || !isTransient(err) || retries >= options.maxPageRetries {
+			break
+		}
+
+		if waitErr := wait(pagesCtx, d.retryPolicy(request).backoff(retries)); waitErr != nil {
+			pastDeadline = errors.Is(waitErr, errWaitPastDeadline)
+
+			break
+		}
+
+		if options.retryMode == OperationRestart {
+			result.Objects = nil
+			cursor = initialCursor
+		}
+	}
+
+	// Paging also stops before the deadline rather than waiting past it, e.g.
+	// for the interval between pages or the backoff of a page retry, while
+	// the context is not done yet.
+	pastDeadline = pastDeadline || isWaitPastDeadline(err)
+
+	switch {
+	case err == nil:
+	case ctx.Err() == nil && (errors.Is(pagesCtx.Err(), context.DeadlineExceeded) || pastDeadline && options.ownsDeadline(ctx)):
+		// The page that was being fetched when the deadline was exceeded is
+		// fetched again when resuming.
+		result.NextCursor = cursor
+		result.DeadlineExceeded = true
+	default:
+		result.NextCursor = cursor
+
+		return result, err
+	}
+
+	if options.sortByUniqueID {
+		sortByUniqueID(result.Objects, ValidEntityExternalIDs[request.EntityExternalID].uniqueIDAttrExternalID)
+	}
+
+	return result, nil
+}
+
+// EntityPagesResult is the result of paging an entity with GetEntitiesPages.
+type EntityPagesResult struct {
+	// Result is the result of GetAllPages for the entity.
+	// Nil if the entity was skipped.
+	Result *PagesResult
+
+	// Err is the error returned by GetAllPages for the entity.
+	// Nil if the entity was paged successfully or skipped.
+	Err *framework.Error
+
+	// Skipped is the reason why the entity was not paged.
+	// Empty if the entity was paged.
+	Skipped string
+}
+
+// GetEntitiesPages returns the objects of all the pages of each entity, as
+// returned by GetAllPages for the request with the entity's external ID, e.g.
+// to sync several entities with the same base URL and credentials.
+// The request's attributes and cursor are not used.
+// If skipForbidden is true, entities the token lacks the scope to access are
+// logged and skipped instead of failing, as with ValidateAllEntities.
+// At most concurrency entities are paged at the same time.
+func (d *Datasource) GetEntitiesPages(
+	ctx context.Context, request *Request, entityIDs []string, concurrency int, skipForbidden bool, opts ...PagesOption,
+) map[string]EntityPagesResult {
+	results, _ := fanOut(ctx, entityIDs, concurrency, func(ctx context.Context, entityID string) (EntityPagesResult, *framework.Error) {
+		entityRequest := *request
+		entityRequest.EntityExternalID = entityID
+		entityRequest.Attributes = nil
+		entityRequest.Cursor = ""
+
+		result, err := d.GetAllPages(ctx, &entityRequest, opts...)
+
+		if skipForbidden && isInsufficientScopeError(err) {
+			d.logf("Skipping entity %s: token lacks the required scope: %s", entityID, err.Message)
+
+			return EntityPagesResult{Skipped: "Token lacks the scope required to access the entity."}, nil
+		}
+
+		return EntityPagesResult{Result: result, Err: err}, nil
+	})
+
+	return results
+}
+
+// sortByUniqueID stably sorts the objects by the value of their unique ID
+// attribute, objects without a unique ID being sorted last.
+func sortByUniqueID(objects []map[string]any, uniqueIDAttr string) {
+	slices.SortStableFunc(objects, func(a, b map[string]any) int {
+		idA, okA := a[uniqueIDAttr].(string)
+		idB, okB := b[uniqueIDAttr].(string)
+
+		switch {
+		case okA && okB:
+			return strings.Compare(idA, idB)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+// WithMinPageInterval sets the minimum delay between the starts of consecutive
+// page requests in StreamPages and GetAllPages, e.g. to be gentle on the
+// datasource during large backfills.
+// This is independent of any rate limiting of individual requests.
+func WithMinPageInterval(interval time.Duration) ClientOption {
+	return func(d *Datasource) {
+		d.MinPageInterval = interval
+	}
+}
+
+// filter returns the objects that satisfy all the filters.
+func (o *pagesOptions) filter(objects []map[string]any) []map[string]any {
+	if len(o.filters) == 0 {
+		return objects
+	}
+
+	filtered := make([]map[string]any, 0, len(objects))
+
+	for _, object := range objects {
+		if o.matches(object) {
+			filtered = append(filtered, object)
+		}
+	}
+
+	return filtered
+}
+
+func (o *pagesOptions) matches(object map[string]any) bool {
+	for _, predicate := range o.filters {
+		if !predicate(object) {
+			return false
+		}
+	}
+
+	return true
+}
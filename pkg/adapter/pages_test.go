@@ -0,0 +1,283 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// usersPagesHandler returns a handler listing the users by offset, one per
+// page, failing the requests at the offsets of failures with their status code
+// once each, and counting the requests.
+func usersPagesHandler(users []string, failures map[int64]int, requests *atomic.Int32) http.HandlerFunc {
+	var failed sync.Map
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+
+		if statusCode, found := failures[offset]; found {
+			if _, loaded := failed.LoadOrStore(offset, true); !loaded {
+				w.WriteHeader(statusCode)
+
+				return
+			}
+		}
+
+		if offset >= int64(len(users)) {
+			w.Write([]byte(`{"users":[],"more":false,"limit":1,"offset":` + strconv.FormatInt(offset, 10) + `}`))
+
+			return
+		}
+
+		fmt.Fprintf(w, `{"users":[{"id":%q}],"more":%t,"limit":1,"offset":%d}`, users[offset], offset < int64(len(users))-1, offset)
+	}
+}
+
+func TestGetAllPagesDeadlineBeforeMinPageInterval(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, usersPagesHandler([]string{"U1", "U2"}, nil, &requests))
+
+	request := newTestRequest(server, Users)
+	request.PageSize = 1
+
+	// The interval between pages ends after the deadline, so paging stops
+	// after the first page rather than failing.
+	datasource := NewClient(5, WithMinPageInterval(time.Hour)).(*Datasource)
+
+	result, err := datasource.GetAllPages(context.Background(), request, WithDeadline(time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, &PagesResult{
+		Objects:          []map[string]any{{"id": "U1"}},
+		NextCursor:       "1",
+		DeadlineExceeded: true,
+	}, result)
+	AssertDeepEqual(t, int32(1), requests.Load())
+}
+
+func TestGetAllPagesDeadlineBeforePageRetry(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, usersPagesHandler([]string{"U1", "U2"}, map[int64]int{1: http.StatusServiceUnavailable}, &requests))
+
+	request := newTestRequest(server, Users)
+	request.PageSize = 1
+	request.RetryPolicy = &RetryPolicy{InitialBackoff: time.Hour}
+
+	result, err := NewClient(5).(*Datasource).GetAllPages(context.Background(), request,
+		WithDeadline(time.Now().Add(time.Minute)), WithPageRetries(PageRetry, 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, &PagesResult{
+		Objects:          []map[string]any{{"id": "U1"}},
+		NextCursor:       "1",
+		DeadlineExceeded: true,
+	}, result)
+}
+
+func TestGetAllPagesContextDeadlineBeforePageRetry(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, usersPagesHandler([]string{"U1", "U2"}, map[int64]int{1: http.StatusServiceUnavailable}, &requests))
+
+	request := newTestRequest(server, Users)
+	request.PageSize = 1
+	request.RetryPolicy = &RetryPolicy{InitialBackoff: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Without WithDeadline, the page error is returned.
+	result, err := NewClient(5).(*Datasource).GetAllPages(ctx, request, WithPageRetries(PageRetry, 1))
+	if err == nil {
+		t.Fatal("Expected the page error")
+	}
+
+	AssertDeepEqual(t, "1", result.NextCursor)
+	AssertDeepEqual(t, false, result.DeadlineExceeded)
+}
+
+func TestGetAllPagesRetryModes(t *testing.T) {
+	tests := map[string]struct {
+		opts         []PagesOption
+		wantResult   *PagesResult
+		wantErr      bool
+		wantRequests int32
+	}{
+		"no_retries": {
+			wantResult: &PagesResult{
+				Objects:    []map[string]any{{"id": "U1"}},
+				NextCursor: "1",
+			},
+			wantErr:      true,
+			wantRequests: 2,
+		},
+		"page_retry": {
+			opts: []PagesOption{WithPageRetries(PageRetry, 1)},
+			wantResult: &PagesResult{
+				Objects: []map[string]any{{"id": "U1"}, {"id": "U2"}, {"id": "U3"}},
+			},
+			wantRequests: 4,
+		},
+		"operation_restart": {
+			opts: []PagesOption{WithPageRetries(OperationRestart, 1)},
+			wantResult: &PagesResult{
+				Objects: []map[string]any{{"id": "U1"}, {"id": "U2"}, {"id": "U3"}},
+			},
+			wantRequests: 5,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			// The second page fails once.
+			server := newTestServer(t, usersPagesHandler([]string{"U1", "U2", "U3"}, map[int64]int{1: http.StatusServiceUnavailable}, &requests))
+
+			request := newTestRequest(server, Users)
+			request.PageSize = 1
+			request.RetryPolicy = &RetryPolicy{InitialBackoff: time.Millisecond}
+
+			result, err := NewClient(5).(*Datasource).GetAllPages(context.Background(), request, tt.opts...)
+
+			AssertDeepEqual(t, tt.wantErr, err != nil)
+			AssertDeepEqual(t, tt.wantResult, result)
+			AssertDeepEqual(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+// auditRecordsPagesHandler returns a handler listing the audit records by
+// cursor, one per page, failing the request for the cursor of failure once.
+func auditRecordsPagesHandler(records []string, failure string) http.HandlerFunc {
+	var failed atomic.Bool
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+
+		if cursor == failure && !failed.Swap(true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		index := 0
+		if cursor != "" {
+			index, _ = strconv.Atoi(cursor)
+		}
+
+		nextCursor := ""
+		if index < len(records)-1 {
+			nextCursor = strconv.Itoa(index + 1)
+		}
+
+		fmt.Fprintf(w, `{"records":[{"id":%q}],"next_cursor":%q,"more":%t,"limit":1}`, records[index], nextCursor, nextCursor != "")
+	}
+}
+
+func TestGetAllPagesResume(t *testing.T) {
+	tests := map[string]struct {
+		entity     string
+		handler    http.HandlerFunc
+		wantCursor string
+	}{
+		"offset_paging": {
+			entity:     Users,
+			handler:    usersPagesHandler([]string{"P1", "P2", "P3"}, map[int64]int{2: http.StatusServiceUnavailable}, &atomic.Int32{}),
+			wantCursor: "2",
+		},
+		"cursor_paging": {
+			entity:     AuditRecords,
+			handler:    auditRecordsPagesHandler([]string{"P1", "P2", "P3"}, "2"),
+			wantCursor: encodeCursor(&pageCursor{Token: "2"}),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, tt.handler)
+
+			request := newTestRequest(server, tt.entity)
+			request.PageSize = 1
+			request.RetryPolicy = &RetryPolicy{InitialBackoff: time.Millisecond}
+
+			datasource := NewClient(5).(*Datasource)
+
+			// The third page fails, returning the cursor to resume from.
+			result, err := datasource.GetAllPages(context.Background(), request)
+			if err == nil {
+				t.Fatal("Expected the page error")
+			}
+
+			AssertDeepEqual(t, &PagesResult{
+				Objects:    []map[string]any{{"id": "P1"}, {"id": "P2"}},
+				NextCursor: tt.wantCursor,
+			}, result)
+
+			result, err = datasource.GetAllPages(context.Background(), request, WithInitialCursor(result.NextCursor))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, &PagesResult{
+				Objects: []map[string]any{{"id": "P3"}},
+			}, result)
+		})
+	}
+}
+
+func TestGetEntitiesPagesForbidden(t *testing.T) {
+	server := newTestServer(t, forbiddenHandler())
+
+	datasource := NewClient(5).(*Datasource)
+
+	results := datasource.GetEntitiesPages(context.Background(), newTestRequest(server, Users), []string{Users, Teams}, 2, true)
+
+	// Only the insufficient scope is skipped.
+	AssertDeepEqual(t, EntityPagesResult{Skipped: "Token lacks the scope required to access the entity."}, results[Users])
+	AssertDeepEqual(t, EntityPagesResult{Result: &PagesResult{}, Err: forbiddenTeamsError}, results[Teams])
+}
+
+func TestGetEntitiesPages(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, usersPagesHandler([]string{"U1", "U2"}, nil, &requests))
+
+	request := newTestRequest(server, Teams)
+	request.PageSize = 1
+	request.Cursor = "1"
+
+	results := NewClient(5).(*Datasource).GetEntitiesPages(context.Background(), request, []string{Users}, 2, true)
+
+	// Each entity is paged from its first page.
+	AssertDeepEqual(t, map[string]EntityPagesResult{
+		Users: {Result: &PagesResult{Objects: []map[string]any{{"id": "U1"}, {"id": "U2"}}}},
+	}, results)
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+const (
+	// defaultPrefetchTTL is the duration for which a prefetched page is kept if
+	// not specified.
+	defaultPrefetchTTL = time.Minute
+)
+
+// prefetcher fetches the next page of an entity in the background while the
+// current page is being processed, and caches it until it's requested.
+type prefetcher struct {
+	mu      sync.Mutex
+	entries map[string]*prefetchEntry
+
+	// slots bounds the number of prefetch requests in flight. If nil,
+	// prefetching is disabled.
+	slots chan struct{}
+
+	// ttl is the duration for which a prefetched page is kept.
+	ttl time.Duration
+
+	// children indicates whether the first page of the child entity of each
+	// object is also prefetched, for entities with a child entity.
+	children bool
+}
+
+// prefetchEntry is a page being prefetched or already prefetched.
+type prefetchEntry struct {
+	// done is closed once response and err are set.
+	done     chan struct{}
+	response *Response
+	err      *framework.Error
+	expires  time.Time
+}
+
+// WithPrefetch fetches the next page of each successful page in the
+// background, with at most concurrency prefetch requests in flight, and keeps
+// each prefetched page for ttl.
+// A page is only prefetched if a slot is available, so prefetching never
+// exceeds concurrency requests in addition to the requests made by callers.
+// A failed prefetch is not reported: the page is fetched again when requested.
+func WithPrefetch(concurrency int, ttl time.Duration) ClientOption {
+	return func(d *Datasource) {
+		if concurrency <= 0 {
+			concurrency = defaultConcurrency
+		}
+
+		if ttl <= 0 {
+			ttl = defaultPrefetchTTL
+		}
+
+		d.prefetch.slots = make(chan struct{}, concurrency)
+		d.prefetch.ttl = ttl
+	}
+}
+
+// WithChildPrefetch also prefetches the first page of the child entity of
+// each object of entities with a child entity, e.g. the members of each team.
+// Only effective together with WithPrefetch.
+func WithChildPrefetch() ClientOption {
+	return func(d *Datasource) {
+		d.prefetch.children = true
+	}
+}
+
+// enabled returns whether prefetching is enabled.
+func (p *prefetcher) enabled() bool {
+	return p.slots != nil
+}
+
+// take removes and returns the prefetched response for the request, waiting
+// for the prefetch to complete if it's in flight.
+// Returns false if the page was not prefetched, has expired, or its prefetch
+// failed.
+func (p *prefetcher) take(ctx context.Context, key string) (*Response, bool) {
+	p.mu.Lock()
+
+	p.evictExpired()
+
+	entry, found := p.entries[key]
+	delete(p.entries, key)
+
+	p.mu.Unlock()
+
+	if !found {
+		return nil, false
+	}
+
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return nil, false
+	}
+
+	return entry.response, entry.err == nil
+}
+
+// start prefetches the page of the request in the background if a slot is
+// available and the page is not already prefetched.
+func (p *prefetcher) start(ctx context.Context, key string, fetch func(ctx context.Context) (*Response, *framework.Error)) {
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		return
+	}
+
+	entry := &prefetchEntry{
+		done:    make(chan struct{}),
+		expires: time.Now().Add(p.ttl),
+	}
+
+	p.mu.Lock()
+
+	// Prefetched pages that are never requested are evicted as other pages
+	// are prefetched.
+	p.evictExpired()
+
+	if _, found := p.entries[key]; found {
+		p.mu.Unlock()
+		<-p.slots
+
+		return
+	}
+
+	if p.entries == nil {
+		p.entries = make(map[string]*prefetchEntry)
+	}
+
+	p.entries[key] = entry
+
+	p.mu.Unlock()
+
+	// The prefetch outlives the request that triggered it, but not the
+	// prefetched page.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.ttl)
+
+	go func() {
+		defer func() {
+			cancel()
+			<-p.slots
+		}()
+
+		entry.response, entry.err = fetch(ctx)
+
+		close(entry.done)
+	}()
+}
+
+// evictExpired removes the expired entries. Must be called with mu held.
+func (p *prefetcher) evictExpired() {
+	now := time.Now()
+
+	for key, entry := range p.entries {
+		if now.After(entry.expires) {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// prefetchKey returns the key identifying the page of the request.
+func prefetchKey(request *Request) string {
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		// Requests only contain JSON-encodable fields.
+		panic(err)
+	}
+
+	// The key is hashed so that credentials are not kept in the cache keys.
+	sum := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// getPageWithPrefetch returns the page of the request from the prefetched
+// pages if available, or fetches it otherwise, then prefetches the next page
+// and, if enabled, the first page of the children of its objects.
+func (d *Datasource) getPageWithPrefetch(ctx context.Context, request *Request) (*Response, *framework.Error) {
+	key := prefetchKey(request)
+
+	response, found := d.prefetch.take(ctx, key)
+	if !found {
+		var err *framework.Error
+
+		response, err = d.getPage(ctx, request)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return response, nil
+	}
+
+	if response.NextCursor != "" {
+		next := *request
+		next.Cursor = response.NextCursor
+
+		d.prefetch.start(ctx, prefetchKey(&next), func(ctx context.Context) (*Response, *framework.Error) {
+			return d.getPage(ctx, &next)
+		})
+	}
+
+	if entity := ValidEntityExternalIDs[request.EntityExternalID]; d.prefetch.children && entity.childEntity != "" {
+		for _, object := range response.Objects {
+			id, ok := object[entity.uniqueIDAttrExternalID].(string)
+			if !ok || id == "" {
+				continue
+			}
+
+			// Same child request as expandChildren.
+			child := *request
+			child.EntityExternalID = entity.childEntity
+			child.ParentID = id
+			child.Cursor = ""
+			child.Query = ""
+			child.QueryParams = nil
+			child.Options = nil
+			child.Roles = nil
+			child.Attributes = nil
+			child.ExpandChildren = false
+
+			d.prefetch.start(ctx, prefetchKey(&child), func(ctx context.Context) (*Response, *framework.Error) {
+				return d.getPage(ctx, &child)
+			})
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+func TestPrefetcherEvictsExpiredOnStart(t *testing.T) {
+	prefetcher := &prefetcher{slots: make(chan struct{}, 2), ttl: 10 * time.Millisecond}
+
+	fetch := func(context.Context) (*Response, *framework.Error) {
+		return &Response{StatusCode: http.StatusOK}, nil
+	}
+
+	// The first page is prefetched but never taken.
+	prefetcher.start(context.Background(), "first", fetch)
+	<-prefetcher.entries["first"].done
+
+	time.Sleep(20 * time.Millisecond)
+
+	prefetcher.start(context.Background(), "second", fetch)
+
+	prefetcher.mu.Lock()
+	defer prefetcher.mu.Unlock()
+
+	_, found := prefetcher.entries["first"]
+	AssertDeepEqual(t, false, found)
+	AssertDeepEqual(t, 1, len(prefetcher.entries))
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// rateLimiter limits the rate of the requests made by a Datasource, shared by
+// all its requests regardless of their entity, so that concurrent syncs don't
+// exceed the rate limit of the datasource together. Requests with different
+// rates, e.g. set by Request.RequestsPerMinute, take tokens from different
+// buckets, so that they don't reset each other's bucket.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	// buckets are the token buckets, keyed by their rate in requests per
+	// minute.
+	buckets map[int]*tokenBucket
+}
+
+// tokenBucket is a token bucket of a rate.
+type tokenBucket struct {
+	// tokens is the number of requests that can be made without waiting.
+	// Negative if requests are waiting for tokens.
+	tokens float64
+
+	// last is the time tokens were last refilled.
+	last time.Time
+}
+
+// WithRateLimit limits the requests made by the Datasource to
+// requestsPerMinute, unless overridden by Request.RequestsPerMinute.
+// Bursts of up to a second's worth of requests are allowed.
+func WithRateLimit(requestsPerMinute int) ClientOption {
+	return func(d *Datasource) {
+		d.RequestsPerMinute = requestsPerMinute
+	}
+}
+
+// requestsPerMinute returns the rate limit of the request, or zero if
+// requests are not rate limited.
+func (d *Datasource) requestsPerMinute(request *Request) int {
+	if request.RequestsPerMinute > 0 {
+		return request.RequestsPerMinute
+	}
+
+	return d.RequestsPerMinute
+}
+
+// burst returns the capacity of a bucket of the given rate.
+func burst(requestsPerMinute int) float64 {
+	return max(1, float64(requestsPerMinute)/60)
+}
+
+// reserve takes a token from the bucket of the rate and returns how long to
+// wait before the request can be made, along with the saturation of the
+// bucket, i.e. the ratio of the burst used by the requests made or waiting.
+func (l *rateLimiter) reserve(requestsPerMinute int) (time.Duration, float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	capacity := burst(requestsPerMinute)
+	rate := float64(requestsPerMinute) / float64(time.Minute)
+
+	bucket, ok := l.buckets[requestsPerMinute]
+	if !ok {
+		if l.buckets == nil {
+			l.buckets = make(map[int]*tokenBucket)
+		}
+
+		bucket = &tokenBucket{tokens: capacity}
+		l.buckets[requestsPerMinute] = bucket
+	} else {
+		bucket.tokens = min(capacity, bucket.tokens+float64(now.Sub(bucket.last))*rate)
+	}
+
+	bucket.last = now
+	bucket.tokens--
+
+	saturation := 1 - bucket.tokens/capacity
+
+	if bucket.tokens >= 0 {
+		return 0, saturation
+	}
+
+	return time.Duration(-bucket.tokens / rate), saturation
+}
+
+// release returns a token taken by reserve to the bucket of the rate, e.g.
+// when the request is canceled while waiting, so that the requests waiting
+// after it don't wait for it too.
+func (l *rateLimiter) release(requestsPerMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bucket, ok := l.buckets[requestsPerMinute]; ok {
+		bucket.tokens = min(burst(requestsPerMinute), bucket.tokens+1)
+	}
+}
+
+// waitForRateLimit waits until the request can be made within the rate limit
+// of the Datasource.
+func (d *Datasource) waitForRateLimit(ctx context.Context, request *Request) *framework.Error {
+	requestsPerMinute := d.requestsPerMinute(request)
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+
+	delay, saturation := d.limiter.reserve(requestsPerMinute)
+	if delay <= 0 {
+		return nil
+	}
+
+	if d.LogRequests {
+		d.logf("Debug: rate limiter of %d requests/minute is %.0f%% saturated, waiting %s before requesting %s",
+			requestsPerMinute, saturation*100, delay, request.EntityExternalID)
+	}
+
+	if err := wait(ctx, delay); err != nil {
+		d.limiter.release(requestsPerMinute)
+
+		return canceledError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForRateLimitCanceled(t *testing.T) {
+	datasource := NewClient(5, WithRateLimit(60)).(*Datasource)
+	request := &Request{EntityExternalID: Users}
+
+	// The first request takes the only token of the bucket.
+	if err := datasource.waitForRateLimit(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := datasource.waitForRateLimit(ctx, request); err == nil {
+		t.Fatal("Expected an error for the canceled wait")
+	}
+
+	// The token of the canceled request is returned, so the next request only
+	// waits for the token of the first one to be refilled.
+	if delay, _ := datasource.limiter.reserve(60); delay <= 0 || delay > time.Second {
+		t.Errorf("Expected a delay of at most 1s, got %v", delay)
+	}
+}
+
+func TestWaitForRateLimitRates(t *testing.T) {
+	datasource := NewClient(5).(*Datasource)
+
+	if delay, _ := datasource.limiter.reserve(60); delay > 0 {
+		t.Errorf("Expected no delay for the first request, got %v", delay)
+	}
+
+	// Requests with a different rate take tokens from their own bucket...
+	if delay, _ := datasource.limiter.reserve(120); delay > 0 {
+		t.Errorf("Expected no delay for the first request of another rate, got %v", delay)
+	}
+
+	// ...without resetting the bucket of the first rate.
+	if delay, _ := datasource.limiter.reserve(60); delay <= 0 {
+		t.Error("Expected a delay for the second request of the first rate")
+	}
+}
+
+func TestWaitForRateLimitLogsSaturation(t *testing.T) {
+	var logs bytes.Buffer
+
+	datasource := NewClient(5, WithRateLimit(6000), WithRequestLogging()).(*Datasource)
+	datasource.Logger = log.New(&logs, "", 0)
+
+	request := &Request{EntityExternalID: Users}
+
+	// The burst of 100 requests is used, so the next request waits.
+	for i := 0; i <= 100; i++ {
+		if err := datasource.waitForRateLimit(context.Background(), request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if !strings.HasPrefix(logs.String(), "Debug: rate limiter of 6000 requests/minute is 101% saturated, waiting ") ||
+		!strings.HasSuffix(logs.String(), " before requesting users\n") {
+		t.Errorf("Expected the saturation of the rate limiter to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+const (
+	// RegionAttribute is the key under which the name of the region of each
+	// object is added, when paging through multiple regions.
+	RegionAttribute = "_region"
+)
+
+// Region is a PagerDuty service region, e.g. the US region at
+// https://api.pagerduty.com or the EU region at https://api.eu.pagerduty.com.
+type Region struct {
+	// Name identifies the region in cursors and objects, e.g. "us".
+	Name string
+
+	// BaseURL is the base URL of the region's REST API.
+	BaseURL string
+}
+
+// getRegionsPage returns the page at the cursor of the regions of the request,
+// which are paged through in order: the objects of a region are returned once
+// those of the previous regions have all been returned.
+// Objects are tagged with the name of their region under RegionAttribute.
+func (d *Datasource) getRegionsPage(ctx context.Context, request *Request, cursor *pageCursor) (*Response, *framework.Error) {
+	index := 0
+
+	if cursor.Region != "" {
+		index = -1
+
+		for i, region := range request.Regions {
+			if region.Name == cursor.Region {
+				index = i
+			}
+		}
+
+		if index < 0 {
+			return nil, &framework.Error{
+				Message: fmt.Sprintf("Request cursor refers to an unknown region: %s.", cursor.Region),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			}
+		}
+	}
+
+	region := request.Regions[index]
+
+	regionCursor := *cursor
+	regionCursor.Region = ""
+
+	regionRequest := *request
+	regionRequest.BaseURL = region.BaseURL
+	regionRequest.Regions = nil
+	regionRequest.Cursor = ""
+
+	if regionCursor != (pageCursor{}) {
+		regionRequest.Cursor = encodeCursor(&regionCursor)
+	}
+
+	response, err := d.getPage(ctx, &regionRequest)
+	if err != nil {
+		return response, err
+	}
+
+	// A token is only valid in the region of its account.
+	if response.StatusCode == http.StatusUnauthorized {
+		response.ErrorMessage = strings.TrimSpace(
+			fmt.Sprintf("Token is not valid for region %s (%s). %s", region.Name, region.BaseURL, response.ErrorMessage),
+		)
+
+		return response, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return response, nil
+	}
+
+	for _, object := range response.Objects {
+		object[RegionAttribute] = region.Name
+	}
+
+	switch {
+	case response.NextCursor != "":
+		next, cursorErr := parseCursor(response.NextCursor)
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+
+		next.Region = region.Name
+		response.NextCursor = encodeCursor(next)
+	case index+1 < len(request.Regions):
+		response.NextCursor = encodeCursor(&pageCursor{Region: request.Regions[index+1].Name})
+	}
+
+	response.HasMore = response.NextCursor != ""
+
+	return response, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestGetPageRegions(t *testing.T) {
+	us := newTestServer(t, usersPagesHandler([]string{"U1", "U2"}, nil, new(atomic.Int32)))
+
+	// The token is from the US account, so it is rejected by the EU region.
+	eu := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"Authentication failed","code":2006}}`))
+	})
+
+	request := newTestRequest(us, Users)
+	request.PageSize = 1
+	request.Regions = []Region{{Name: "us", BaseURL: us.URL}, {Name: "eu", BaseURL: eu.URL}}
+
+	client := NewClient(5)
+
+	// The cursor within a region refers to the region.
+	response, err := client.GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "U1", RegionAttribute: "us"}}, response.Objects)
+	AssertDeepEqual(t, encodeCursor(&pageCursor{Offset: 1, Region: "us"}), response.NextCursor)
+
+	// The last page of a region hands off to the next region.
+	request.Cursor = response.NextCursor
+
+	response, err = client.GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "U2", RegionAttribute: "us"}}, response.Objects)
+	AssertDeepEqual(t, encodeCursor(&pageCursor{Region: "eu"}), response.NextCursor)
+
+	request.Cursor = response.NextCursor
+
+	response, err = client.GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, &framework.Error{
+		Message: "Failed to authenticate with datasource. Check datasource configuration details and try again. " +
+			"Datasource error 2006: Token is not valid for region eu (" + eu.URL + "). Authentication failed.",
+		Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_AUTH,
+	}, responseError(response))
+}
+
+func TestGetPageUnknownRegion(t *testing.T) {
+	request := &Request{
+		Regions:           []Region{{Name: "us", BaseURL: "https://api.pagerduty.com"}},
+		HTTPAuthorization: "Token token=test",
+		PageSize:          100,
+		EntityExternalID:  Users,
+		Cursor:            encodeCursor(&pageCursor{Region: "ap"}),
+	}
+
+	_, err := NewClient(5).GetPage(context.Background(), request)
+
+	AssertDeepEqual(t, &framework.Error{
+		Message: "Request cursor refers to an unknown region: ap.",
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+	}, err)
+}
@@ -0,0 +1,599 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// ServiceEscalationPolicies inverts the `services` references of the given
+// escalation policy objects into a map from each service ID to the IDs of the
+// escalation policies used by that service.
+// Policies without an ID and references without an ID are ignored.
+func ServiceEscalationPolicies(policies []map[string]any) map[string][]string {
+	servicePolicies := make(map[string][]string)
+
+	for _, policy := range policies {
+		policyID, ok := policy["id"].(string)
+		if !ok || policyID == "" {
+			continue
+		}
+
+		for _, serviceID := range referenceIDs(policy, "services") {
+			servicePolicies[serviceID] = append(servicePolicies[serviceID], policyID)
+		}
+	}
+
+	return servicePolicies
+}
+
+// EscalationLevelTargets is the breakdown of the targets of one level of an
+// escalation policy.
+type EscalationLevelTargets struct {
+	// Level is the 1-based position of the escalation rule in the policy.
+	Level int
+
+	// TargetCounts is the number of targets at this level, keyed by target
+	// type, e.g. "user" or "schedule".
+	// Empty if the level has no targets.
+	TargetCounts map[string]int
+}
+
+// EscalationTargetBreakdown returns, for each of the given escalation policy
+// objects, the number of targets of each type at each level, keyed by policy
+// ID. A level with a single target is a single point of contact.
+// Policies without escalation rules have an empty breakdown.
+func EscalationTargetBreakdown(policies []map[string]any) map[string][]EscalationLevelTargets {
+	breakdowns := make(map[string][]EscalationLevelTargets, len(policies))
+
+	for _, policy := range policies {
+		policyID, ok := policy["id"].(string)
+		if !ok || policyID == "" {
+			continue
+		}
+
+		rules, _ := policy["escalation_rules"].([]any)
+		breakdown := make([]EscalationLevelTargets, 0, len(rules))
+
+		for i, rule := range rules {
+			level := EscalationLevelTargets{
+				Level:        i + 1,
+				TargetCounts: make(map[string]int),
+			}
+
+			ruleObject, _ := rule.(map[string]any)
+			targets, _ := ruleObject["targets"].([]any)
+
+			for _, target := range targets {
+				targetObject, ok := target.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				// Targets are references (e.g. "user_reference") unless expanded.
+				if targetType, ok := targetObject["type"].(string); ok {
+					level.TargetCounts[strings.TrimSuffix(targetType, "_reference")]++
+				}
+			}
+
+			breakdown = append(breakdown, level)
+		}
+
+		breakdowns[policyID] = breakdown
+	}
+
+	return breakdowns
+}
+
+// referenceIDs returns the IDs of the references listed in the given attribute
+// of an object, e.g. the `services` of an escalation policy.
+// Returns nil if the attribute is absent or is not a list of references.
+func referenceIDs(object map[string]any, attribute string) []string {
+	references, ok := object[attribute].([]any)
+	if !ok {
+		return nil
+	}
+
+	ids := make([]string, 0, len(references))
+
+	for _, reference := range references {
+		referenceObject, ok := reference.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if id, ok := referenceObject["id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+const (
+	// TeamNamesAttribute is the key under which the names of the teams of each
+	// user are added by EnrichUsersWithTeamNames.
+	TeamNamesAttribute = "_team_names"
+
+	// MembersAttribute is the key under which the members of each team are
+	// attached when Request.ExpandChildren is set.
+	MembersAttribute = "_members"
+
+	// ResponderRoleAssignee is the role of a user assigned to an incident.
+	ResponderRoleAssignee = "assignee"
+
+	// ResponderRoleAcknowledger is the role of a user who acknowledged an
+	// incident.
+	ResponderRoleAcknowledger = "acknowledger"
+)
+
+// IncidentResponder is a user involved in an incident, either as an assignee
+// or as an acknowledger.
+type IncidentResponder struct {
+	IncidentID string
+	UserID     string
+
+	// Role is either ResponderRoleAssignee or ResponderRoleAcknowledger.
+	Role string
+}
+
+// IncidentResponders returns the users assigned to and who acknowledged each of
+// the given incident objects, from their `assignments` and `acknowledgements`.
+// Unassigned and unacknowledged incidents contribute no responders, and
+// acknowledgements by non-user actors (e.g. integrations) are ignored.
+func IncidentResponders(incidents []map[string]any) []IncidentResponder {
+	var responders []IncidentResponder
+
+	for _, incident := range incidents {
+		incidentID, ok := incident["id"].(string)
+		if !ok || incidentID == "" {
+			continue
+		}
+
+		for _, userID := range userReferenceIDs(incident, "assignments", "assignee") {
+			responders = append(responders, IncidentResponder{IncidentID: incidentID, UserID: userID, Role: ResponderRoleAssignee})
+		}
+
+		for _, userID := range userReferenceIDs(incident, "acknowledgements", "acknowledger") {
+			responders = append(responders, IncidentResponder{IncidentID: incidentID, UserID: userID, Role: ResponderRoleAcknowledger})
+		}
+	}
+
+	return responders
+}
+
+// IncidentMerge is the merge of an incident into another incident.
+type IncidentMerge struct {
+	// SourceID is the ID of the incident that was merged, and resolved as a
+	// result.
+	SourceID string
+
+	// TargetID is the ID of the incident the source incident was merged into.
+	TargetID string
+
+	// MergedAt is the time of the merge, from the resolve log entry of the
+	// source incident. Empty if the log entry is missing.
+	MergedAt string
+}
+
+// IncidentMerges returns the merges of the given incident objects, from the
+// `resolve_reason` of the incidents resolved by being merged into another
+// incident, timestamped with their resolve log entry among the given log entry
+// objects.
+//
+// An incident that was itself merged into a third incident yields a merge for
+// each step, in no particular order; use MergedIncidentRoots to find the
+// incident that each incident ended up in.
+func IncidentMerges(incidents, logEntries []map[string]any) []IncidentMerge {
+	resolvedAt := make(map[string]string)
+
+	for _, logEntry := range logEntries {
+		if logEntryType, _ := logEntry["type"].(string); logEntryType != "resolve_log_entry" {
+			continue
+		}
+
+		incident, _ := logEntry["incident"].(map[string]any)
+		incidentID, _ := incident["id"].(string)
+		createdAt, _ := logEntry["created_at"].(string)
+
+		if incidentID != "" && createdAt != "" {
+			resolvedAt[incidentID] = createdAt
+		}
+	}
+
+	var merges []IncidentMerge
+
+	for _, incident := range incidents {
+		sourceID, _ := incident["id"].(string)
+		reason, _ := incident["resolve_reason"].(map[string]any)
+
+		if reasonType, _ := reason["type"].(string); sourceID == "" || reasonType != "merge_resolve_reason" {
+			continue
+		}
+
+		target, _ := reason["incident"].(map[string]any)
+
+		if targetID, ok := target["id"].(string); ok && targetID != "" && targetID != sourceID {
+			merges = append(merges, IncidentMerge{SourceID: sourceID, TargetID: targetID, MergedAt: resolvedAt[sourceID]})
+		}
+	}
+
+	return merges
+}
+
+// MergedIncidentRoots returns the incident each merged incident ended up in,
+// keyed by source incident ID, following chains of merges, e.g. from A to C if A
+// was merged into B and B into C.
+// Chains are only followed as far as the merges are known, and a cycle in the
+// merges, which can only come from inconsistent data, stops the chain.
+func MergedIncidentRoots(merges []IncidentMerge) map[string]string {
+	targets := make(map[string]string, len(merges))
+
+	for _, merge := range merges {
+		targets[merge.SourceID] = merge.TargetID
+	}
+
+	roots := make(map[string]string, len(targets))
+
+	for sourceID := range targets {
+		root := targets[sourceID]
+		visited := map[string]struct{}{sourceID: {}}
+
+		for {
+			next, found := targets[root]
+			if !found {
+				break
+			}
+
+			if _, cycle := visited[next]; cycle {
+				break
+			}
+
+			visited[root] = struct{}{}
+			root = next
+		}
+
+		roots[sourceID] = root
+	}
+
+	return roots
+}
+
+// userReferenceIDs returns the IDs of the user references found under the
+// given key of each item of the list attribute of an object, e.g. the
+// `assignee` of each of the `assignments` of an incident.
+func userReferenceIDs(object map[string]any, attribute, key string) []string {
+	items, ok := object[attribute].([]any)
+	if !ok {
+		return nil
+	}
+
+	var ids []string
+
+	for _, item := range items {
+		itemObject, _ := item.(map[string]any)
+		reference, _ := itemObject[key].(map[string]any)
+
+		if referenceType, _ := reference["type"].(string); referenceType != "user_reference" && referenceType != "user" {
+			continue
+		}
+
+		if id, ok := reference["id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// GetIncidentSubscribers returns the status update subscribers of each of the
+// given incidents, keyed by incident ID, fetching at most concurrency
+// incidents at the same time.
+// Errors are returned per incident, and an incident failing doesn't prevent
+// the subscribers of the other incidents from being returned.
+func (d *Datasource) GetIncidentSubscribers(
+	ctx context.Context, request *Request, incidentIDs []string, concurrency int,
+) (map[string][]map[string]any, map[string]*framework.Error) {
+	return fanOut(ctx, incidentIDs, concurrency, func(ctx context.Context, incidentID string) ([]map[string]any, *framework.Error) {
+		// The incidents' filters, projection and expansion don't apply to
+		// their subscribers.
+		subscribersRequest := *request
+		subscribersRequest.EntityExternalID = IncidentSubscribers
+		subscribersRequest.ParentID = incidentID
+		subscribersRequest.Cursor = ""
+		subscribersRequest.Query = ""
+		subscribersRequest.QueryParams = nil
+		subscribersRequest.Options = nil
+		subscribersRequest.Roles = nil
+		subscribersRequest.Attributes = nil
+		subscribersRequest.ExpandChildren = false
+
+		subscribers, err := d.GetAllPages(ctx, &subscribersRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		return subscribers.Objects, nil
+	})
+}
+
+// GetEscalationPolicySchedules returns the schedules targeted by the given
+// escalation policy objects, keyed by schedule ID, fetching at most
+// concurrency schedules at the same time.
+// Schedules referenced by multiple policies or levels are only fetched once.
+// Errors are returned per schedule ID, e.g. a not-found error for a deleted
+// schedule, without preventing the other schedules from being returned.
+func (d *Datasource) GetEscalationPolicySchedules(
+	ctx context.Context, request *Request, policies []map[string]any, concurrency int,
+) (map[string]map[string]any, map[string]*framework.Error) {
+	scheduleIDs := escalationTargetIDs(policies, "schedule")
+
+	return fanOut(ctx, scheduleIDs, concurrency, func(ctx context.Context, scheduleID string) (map[string]any, *framework.Error) {
+		scheduleRequest := *request
+		scheduleRequest.EntityExternalID = Schedules
+
+		return d.GetObject(ctx, &scheduleRequest, scheduleID)
+	})
+}
+
+// escalationTargetIDs returns the distinct IDs of the targets of the given type
+// (e.g. "schedule" or "user") across all the levels of the escalation policy
+// objects, in order of first occurrence.
+func escalationTargetIDs(policies []map[string]any, targetType string) []string {
+	var ids []string
+
+	seen := make(map[string]struct{})
+
+	for _, policy := range policies {
+		rules, _ := policy["escalation_rules"].([]any)
+
+		for _, rule := range rules {
+			ruleObject, _ := rule.(map[string]any)
+			targets, _ := ruleObject["targets"].([]any)
+
+			for _, target := range targets {
+				targetObject, _ := target.(map[string]any)
+
+				if objectType, _ := targetObject["type"].(string); strings.TrimSuffix(objectType, "_reference") != targetType {
+					continue
+				}
+
+				id, _ := targetObject["id"].(string)
+				if _, found := seen[id]; id == "" || found {
+					continue
+				}
+
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+// GetEscalationPolicyGraph returns the escalation policy with the given ID,
+// with the targets of its levels replaced by the full user and schedule objects
+// they reference, fetching at most concurrency targets at the same time.
+//
+// The policy is requested with `include[]=targets`, so that only the targets
+// that are not embedded are fetched, each distinct target once. Targets that
+// could not be fetched (e.g. deleted schedules) are left as references, and
+// their errors are returned keyed by target ID.
+func (d *Datasource) GetEscalationPolicyGraph(
+	ctx context.Context, request *Request, policyID string, concurrency int,
+) (map[string]any, map[string]*framework.Error, *framework.Error) {
+	policyRequest := *request
+	policyRequest.EntityExternalID = EscalationPolicies
+
+	policy, err := d.getObject(ctx, &policyRequest, policyID, url.Values{"include[]": {"targets"}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		targets   []map[string]any
+		targetIDs []string
+	)
+
+	rules, _ := policy["escalation_rules"].([]any)
+
+	for _, rule := range rules {
+		ruleObject, _ := rule.(map[string]any)
+		ruleTargets, _ := ruleObject["targets"].([]any)
+
+		for _, target := range ruleTargets {
+			targetObject, _ := target.(map[string]any)
+			targetType, _ := targetObject["type"].(string)
+			id, _ := targetObject["id"].(string)
+
+			if id == "" || (targetType != "user_reference" && targetType != "schedule_reference") {
+				continue
+			}
+
+			targets = append(targets, targetObject)
+			targetIDs = append(targetIDs, targetType+"/"+id)
+		}
+	}
+
+	resolved, errs := fanOut(ctx, targetIDs, concurrency, func(ctx context.Context, key string) (map[string]any, *framework.Error) {
+		targetType, id, _ := strings.Cut(key, "/")
+
+		targetRequest := *request
+		targetRequest.EntityExternalID = Users
+
+		if targetType == "schedule_reference" {
+			targetRequest.EntityExternalID = Schedules
+		}
+
+		return d.GetObject(ctx, &targetRequest, id)
+	})
+
+	for i, target := range targets {
+		if object, found := resolved[targetIDs[i]]; found {
+			clear(target)
+			maps.Copy(target, object)
+		}
+	}
+
+	// Errors are keyed by target ID alone, as IDs are unique across entities.
+	targetErrs := make(map[string]*framework.Error, len(errs))
+
+	for key, targetErr := range errs {
+		_, id, _ := strings.Cut(key, "/")
+		targetErrs[id] = targetErr
+	}
+
+	return policy, targetErrs, nil
+}
+
+// EnrichUsersWithTeamNames adds the names of the teams referenced by the
+// `teams` attribute of each of the given user objects under the
+// TeamNamesAttribute key, fetching each distinct team once with at most
+// concurrency teams fetched at the same time.
+// Teams that could not be fetched (e.g. deleted teams) are left out of the
+// names, and their errors are returned keyed by team ID.
+func (d *Datasource) EnrichUsersWithTeamNames(
+	ctx context.Context, request *Request, users []map[string]any, concurrency int,
+) map[string]*framework.Error {
+	var teamIDs []string
+
+	for _, user := range users {
+		teamIDs = append(teamIDs, referenceIDs(user, "teams")...)
+	}
+
+	teams, errs := fanOut(ctx, teamIDs, concurrency, func(ctx context.Context, teamID string) (map[string]any, *framework.Error) {
+		teamRequest := *request
+		teamRequest.EntityExternalID = Teams
+
+		return d.GetObject(ctx, &teamRequest, teamID)
+	})
+
+	for _, user := range users {
+		names := []string{}
+
+		for _, teamID := range referenceIDs(user, "teams") {
+			if name, ok := teams[teamID]["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+
+		user[TeamNamesAttribute] = names
+	}
+
+	return errs
+}
+
+// GetSupportingServices returns the dependency relationships in which the
+// given business service depends on a supporting service, including the
+// relationship metadata (`id`, `type`) and the `supporting_service` reference.
+// Returns an empty list if the business service has no supporting services.
+func (d *Datasource) GetSupportingServices(
+	ctx context.Context, request *Request, businessServiceID string,
+) ([]map[string]any, *framework.Error) {
+	dependenciesRequest := *request
+	dependenciesRequest.EntityExternalID = BusinessServiceDependencies
+	dependenciesRequest.Attributes = nil
+	dependenciesRequest.ParentID = businessServiceID
+	dependenciesRequest.Cursor = ""
+
+	dependencies, err := d.GetAllPages(ctx, &dependenciesRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// The relationships also include those in which the business service
+	// supports other business services.
+	supporting := []map[string]any{}
+
+	for _, relationship := range dependencies.Objects {
+		dependent, _ := relationship["dependent_service"].(map[string]any)
+
+		if id, _ := dependent["id"].(string); id == businessServiceID {
+			supporting = append(supporting, relationship)
+		}
+	}
+
+	return supporting, nil
+}
+
+// expandChildren fetches all the child objects of each of the given objects of
+// the entity, with at most Request.ChildConcurrency objects at the same time,
+// and attaches them under the entity's childAttr.
+// Returns an error for the first object whose children could not be fetched,
+// rather than leaving the relationship out.
+func (d *Datasource) expandChildren(
+	ctx context.Context, request *Request, entity Entity, objects []map[string]any,
+) *framework.Error {
+	ids := make([]string, 0, len(objects))
+
+	for _, object := range objects {
+		if id, ok := object[entity.uniqueIDAttrExternalID].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	children, errs := fanOut(ctx, ids, request.ChildConcurrency, func(ctx context.Context, id string) ([]map[string]any, *framework.Error) {
+		// The parent's filters, projection and expansion don't apply to its
+		// children.
+		childRequest := *request
+		childRequest.EntityExternalID = entity.childEntity
+		childRequest.ParentID = id
+		childRequest.Cursor = ""
+		childRequest.Query = ""
+		childRequest.QueryParams = nil
+		childRequest.Options = nil
+		childRequest.Roles = nil
+		childRequest.Attributes = nil
+		childRequest.ExpandChildren = false
+
+		result, err := d.GetAllPages(ctx, &childRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Objects == nil {
+			return []map[string]any{}, nil
+		}
+
+		return result.Objects, nil
+	})
+
+	for _, id := range ids {
+		if err, found := errs[id]; found {
+			return &framework.Error{
+				Message:    fmt.Sprintf("Failed to fetch the %s of %s: %s", entity.childEntity, id, err.Message),
+				Code:       err.Code,
+				RetryAfter: err.RetryAfter,
+			}
+		}
+	}
+
+	for _, object := range objects {
+		id, _ := object[entity.uniqueIDAttrExternalID].(string)
+
+		if objectChildren, found := children[id]; found {
+			object[entity.childAttr] = objectChildren
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestGetPageExpandChildrenWithAttributes(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/teams":
+			w.Write([]byte(`{"teams":[{"id":"T1","name":"Team 1","summary":"Team 1"}],"more":false,"limit":100,"offset":0}`))
+		case "/teams/T1/members":
+			w.Write([]byte(`{"members":[{"user":{"id":"U1"},"role":"manager"}],"more":false,"limit":100,"offset":0}`))
+		default:
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+	})
+
+	request := newTestRequest(server, Teams)
+	request.Attributes = []string{"name"}
+	request.ExpandChildren = true
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The attributes of the teams don't apply to their members.
+	AssertDeepEqual(t, []map[string]any{
+		{
+			"id":   "T1",
+			"name": "Team 1",
+			MembersAttribute: []map[string]any{
+				{"id": "T1" + CompositeIDSeparator + "U1", "user": map[string]any{"id": "U1"}, "role": "manager"},
+			},
+		},
+	}, response.Objects)
+}
+
+func TestGetPageExpandChildren(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/teams":
+			w.Write([]byte(`{"teams":[{"id":"T1"},{"id":"T2"}],"more":false,"limit":100,"offset":0}`))
+		case "/teams/T1/members":
+			w.Write([]byte(`{"members":[{"user":{"id":"U1"},"role":"manager"},{"user":{"id":"U2"},"role":"responder"}],"more":false,"limit":100,"offset":0}`))
+		case "/teams/T2/members":
+			w.Write([]byte(`{"members":[{"user":{"id":"U2"},"role":"manager"},{"user":{"id":"U3"},"role":"observer"}],"more":false,"limit":100,"offset":0}`))
+		default:
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+	})
+
+	request := newTestRequest(server, Teams)
+	request.ExpandChildren = true
+	request.ChildConcurrency = 1
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	member := func(teamID, userID, role string) map[string]any {
+		return map[string]any{"id": teamID + CompositeIDSeparator + userID, "user": map[string]any{"id": userID}, "role": role}
+	}
+
+	AssertDeepEqual(t, []map[string]any{
+		{
+			"id":             "T1",
+			MembersAttribute: []map[string]any{member("T1", "U1", "manager"), member("T1", "U2", "responder")},
+		},
+		{
+			"id":             "T2",
+			MembersAttribute: []map[string]any{member("T2", "U2", "manager"), member("T2", "U3", "observer")},
+		},
+	}, response.Objects)
+}
+
+func TestGetPageExpandChildrenError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/teams":
+			w.Write([]byte(`{"teams":[{"id":"T1"},{"id":"T2"}],"more":false,"limit":100,"offset":0}`))
+		case "/teams/T1/members":
+			w.Write([]byte(`{"members":[],"more":false,"limit":100,"offset":0}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	request := newTestRequest(server, Teams)
+	request.ExpandChildren = true
+
+	// A failed child request fails the page rather than leaving the members
+	// out.
+	_, err := NewClient(5).GetPage(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected an error for the failed members request")
+	}
+
+	AssertDeepEqual(t, api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED, err.Code)
+}
+
+func TestGetPageBusinessServiceDependenciesWithoutParent(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/business_services":
+			w.Write([]byte(`{"business_services":[{"id":"B1"}],"more":false,"limit":1,"offset":0}`))
+		case "/service_dependencies/business_services/B1":
+			w.Write([]byte(`{"relationships":[{"id":"R1","supporting_service":{"id":"S1"},"dependent_service":{"id":"B1"}}]}`))
+		default:
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+	})
+
+	// The business services are discovered to list their dependencies.
+	response, err := NewClient(5).GetPage(context.Background(), newTestRequest(server, BusinessServiceDependencies))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{
+		{"id": "R1", "supporting_service": map[string]any{"id": "S1"}, "dependent_service": map[string]any{"id": "B1"}},
+	}, response.Objects)
+	AssertDeepEqual(t, "", response.NextCursor)
+}
+
+func TestGetIncidentSubscribersIncidentsRequest(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		AssertDeepEqual(t, "/incidents/Q1/status_updates/subscribers", r.URL.Path)
+		AssertDeepEqual(t, "limit=100&offset=0", r.URL.RawQuery)
+
+		w.Write([]byte(`{"subscribers":[{"subscriber_id":"U1","subscriber_type":"user"}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	// The incidents' filters and projection don't apply to their subscribers.
+	request := newTestRequest(server, Incidents)
+	request.Query = "urgency=high"
+	request.QueryParams = map[string][]string{"statuses[]": {"triggered"}}
+	request.Options = &PageOptions{SortBy: "created_at:desc"}
+	request.Attributes = []string{"title"}
+	request.ExpandChildren = true
+
+	subscribers, errs := NewClient(5).(*Datasource).GetIncidentSubscribers(context.Background(), request, []string{"Q1"}, 1)
+
+	AssertDeepEqual(t, map[string]*framework.Error{}, errs)
+	AssertDeepEqual(t, map[string][]map[string]any{
+		"Q1": {{"subscriber_id": "U1", "subscriber_type": "user"}},
+	}, subscribers)
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxFixtureNameLength is the maximum length of fixture file names derived
+	// from the request host, path and query. Longer names are replaced by a hash.
+	maxFixtureNameLength = 200
+)
+
+// fixture is a recorded datasource response, stored as a JSON file.
+type fixture struct {
+	StatusCode int             `json:"status_code"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// ReplayOptions configures the failures simulated when replaying fixtures.
+type ReplayOptions struct {
+	// RateLimitEvery is the number of requests after which a request is
+	// rejected with a 429 and a Retry-After of 1 second.
+	// Optional. If zero, requests are not rate limited.
+	RateLimitEvery int
+
+	// TruncateEvery is the number of requests after which the body of a
+	// response is truncated mid-way.
+	// Optional. If zero, bodies are not truncated.
+	TruncateEvery int
+
+	// Latency is the delay before each response is returned.
+	// Optional. If zero, responses are returned immediately.
+	Latency time.Duration
+}
+
+// WithReplay serves the responses of the Datasource from the fixtures
+// recorded in dir with WithRecording, instead of requesting the datasource,
+// e.g. to test the adapter without a PagerDuty account.
+// A request without fixture returns a 404.
+func WithReplay(dir string, opts ReplayOptions) ClientOption {
+	return func(d *Datasource) {
+		d.transport.replay = &replayTransport{dir: dir, opts: opts}
+	}
+}
+
+// WithRecording records the responses of the datasource as fixtures in dir,
+// to be replayed with WithReplay. Existing fixtures are overwritten.
+func WithRecording(dir string) ClientOption {
+	return func(d *Datasource) {
+		d.transport.recordDir = dir
+	}
+}
+
+// fixtureName returns the name of the fixture file of the request, derived
+// from its host, path and query, e.g.
+// "api.pagerduty.com%2Fusers%3Flimit=100&offset=0.json", so that the
+// fixtures of regions or other datasources recorded in the same directory
+// don't overwrite each other.
+func fixtureName(req *http.Request) string {
+	key := req.URL.Host + "/" + strings.Trim(req.URL.Path, "/")
+
+	// Encode sorts the query parameters, so that the name doesn't depend on
+	// their order.
+	if query := req.URL.Query().Encode(); query != "" {
+		key += "?" + query
+	}
+
+	name := strings.NewReplacer("/", "%2F", "?", "%3F", ":", "%3A").Replace(key)
+
+	if len(name) > maxFixtureNameLength {
+		sum := sha256.Sum256([]byte(key))
+		name = hex.EncodeToString(sum[:])
+	}
+
+	return name + ".json"
+}
+
+// replayTransport is an HTTP transport serving fixtures.
+type replayTransport struct {
+	dir  string
+	opts ReplayOptions
+
+	// requests is the number of requests served.
+	requests atomic.Int64
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := int(t.requests.Add(1))
+
+	if t.opts.Latency > 0 {
+		if err := wait(req.Context(), t.opts.Latency); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.opts.RateLimitEvery > 0 && n%t.opts.RateLimitEvery == 0 {
+		return replayResponse(req, &fixture{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"1"}},
+			Body:       json.RawMessage(`{"error": {"message": "Rate Limit Exceeded", "code": 2020}}`),
+		}, false), nil
+	}
+
+	name := fixtureName(req)
+
+	data, err := os.ReadFile(filepath.Join(t.dir, name))
+	if err != nil {
+		return replayResponse(req, &fixture{
+			StatusCode: http.StatusNotFound,
+			Body:       json.RawMessage(fmt.Sprintf(`{"error": {"message": %q}}`, "Replay fixture not found: "+name)),
+		}, false), nil
+	}
+
+	var recorded fixture
+
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("invalid replay fixture %s: %w", name, err)
+	}
+
+	return replayResponse(req, &recorded, t.opts.TruncateEvery > 0 && n%t.opts.TruncateEvery == 0), nil
+}
+
+// replayResponse returns the response of the fixture. A truncated response
+// fails with io.ErrUnexpectedEOF after half of its body.
+func replayResponse(req *http.Request, f *fixture, truncate bool) *http.Response {
+	header := f.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	var body io.Reader = bytes.NewReader(f.Body)
+	if truncate {
+		body = io.MultiReader(bytes.NewReader(f.Body[:len(f.Body)/2]), truncatedReader{})
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode: f.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       io.NopCloser(body),
+		Request:    req,
+	}
+}
+
+// truncatedReader fails as a body truncated by the connection being closed.
+type truncatedReader struct{}
+
+func (truncatedReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+// recordingTransport is an HTTP transport recording the responses of the
+// datasource as fixtures.
+type recordingTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := decodedBody(res)
+	if err != nil {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(resBody)
+	res.Body.Close()
+
+	// The body is replaced by the decoded body that was read, so the response
+	// is returned as if it wasn't recorded.
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = int64(len(body))
+	res.Uncompressed = true
+
+	// Incomplete or non-JSON bodies, e.g. HTML error pages of a proxy, are not
+	// recorded.
+	if err != nil || !json.Valid(body) {
+		return res, nil
+	}
+
+	data, err := json.MarshalIndent(&fixture{StatusCode: res.StatusCode, Header: res.Header, Body: body}, "", "  ")
+	if err != nil {
+		return res, nil
+	}
+
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(t.dir, fixtureName(req)), data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to record fixture: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFixtureName(t *testing.T) {
+	tests := map[string]struct {
+		url  string
+		want string
+	}{
+		"query_sorted": {
+			url:  "https://api.pagerduty.com/users?offset=0&limit=100",
+			want: "api.pagerduty.com%2Fusers%3Flimit=100&offset=0.json",
+		},
+		"other_host": {
+			url:  "https://api.eu.pagerduty.com/users?offset=0&limit=100",
+			want: "api.eu.pagerduty.com%2Fusers%3Flimit=100&offset=0.json",
+		},
+		"port": {
+			url:  "http://127.0.0.1:8080/schedules/P1/overrides",
+			want: "127.0.0.1%3A8080%2Fschedules%2FP1%2Foverrides.json",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			AssertDeepEqual(t, tt.want, fixtureName(req))
+		})
+	}
+}
+
+// recordUsers records the fixture of a page of users in dir and returns the
+// request of the page.
+func recordUsers(t *testing.T, dir string) *Request {
+	t.Helper()
+
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"U1"},{"id":"U2"}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Users)
+
+	if _, err := NewClient(5, WithRecording(dir)).GetPage(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The fixtures are replayed without the datasource.
+	server.Close()
+
+	return request
+}
+
+func TestGetPageReplayRecording(t *testing.T) {
+	dir := t.TempDir()
+	request := recordUsers(t, dir)
+
+	response, err := NewClient(5, WithReplay(dir, ReplayOptions{})).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, http.StatusOK, response.StatusCode)
+	AssertDeepEqual(t, []map[string]any{{"id": "U1"}, {"id": "U2"}}, response.Objects)
+
+	// Requests without fixture return a 404.
+	request.EntityExternalID = Teams
+
+	response, err = NewClient(5, WithReplay(dir, ReplayOptions{})).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestGetPageReplayFailures(t *testing.T) {
+	tests := map[string]struct {
+		opts        ReplayOptions
+		wantRetries int
+	}{
+		"rate_limited": {
+			opts:        ReplayOptions{RateLimitEvery: 2},
+			wantRetries: 1,
+		},
+		"truncated": {
+			opts:        ReplayOptions{TruncateEvery: 2},
+			wantRetries: 1,
+		},
+		"none": {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			request := recordUsers(t, dir)
+			request.RetryPolicy = &RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}
+
+			client := NewClient(5, WithReplay(dir, tt.opts))
+
+			// The second request fails, and is retried with the third.
+			for i, wantRetries := range []int{0, tt.wantRetries} {
+				response, err := client.GetPage(context.Background(), request)
+				if err != nil {
+					t.Fatalf("Unexpected error for page %d: %v", i, err)
+				}
+
+				AssertDeepEqual(t, http.StatusOK, response.StatusCode)
+				AssertDeepEqual(t, []map[string]any{{"id": "U1"}, {"id": "U2"}}, response.Objects)
+				AssertDeepEqual(t, wantRetries, response.Retries)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/sgnl-ai/adapter-framework/web"
+)
+
+var (
+	// transientStatusCodes is the list of HTTP status codes of server errors
+	// that may not occur again if the request is retried.
+	transientStatusCodes = []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	// errWaitPastDeadline is returned by wait if the context's deadline is
+	// before the end of the wait, while the context is not done yet.
+	errWaitPastDeadline = errors.New("context deadline is before the end of the wait")
+)
+
+const (
+	// defaultInitialBackoff is the delay before the first retry if the
+	// RetryPolicy doesn't specify one.
+	defaultInitialBackoff = time.Second
+
+	// defaultMaxRateLimitRetries is the maximum number of retries of a
+	// rate-limited request if the RetryPolicy doesn't specify one.
+	defaultMaxRateLimitRetries = 3
+
+	// defaultMaxRateLimitWait is the maximum total delay before the retries of
+	// a rate-limited request if the RetryPolicy doesn't specify one.
+	defaultMaxRateLimitWait = time.Minute
+)
+
+// RetryPolicy configures the retries of requests that failed with a transient
+// error.
+//
+// GET requests are retried according to the policy. Other requests, e.g. the
+// POST requests of analytics, are only retried if they carry an idempotency key
+// (see Request.IdempotencyKey) or if the datasource refused the connection,
+// since the datasource may otherwise have executed them already.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times a request that failed with a
+	// transient error (e.g. a 503 or a truncated body) is retried.
+	// If zero, requests are not retried, except for rate-limited requests.
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry, doubled for each
+	// subsequent retry and randomized by up to half. A longer Retry-After
+	// returned by the datasource takes precedence.
+	// Optional. Defaults to 1 second.
+	InitialBackoff time.Duration
+
+	// RetryableErrorCodes is the list of PagerDuty error codes, as returned in
+	// the `error.code` field of unsuccessful response bodies, for which
+	// requests are retried regardless of the HTTP status code.
+	// Optional.
+	RetryableErrorCodes []int
+
+	// MaxRateLimitRetries is the maximum number of times a request rejected
+	// with a 429 status code is retried, independently of MaxRetries.
+	// Optional. Defaults to 3. If negative, rate-limited requests are not
+	// retried.
+	MaxRateLimitRetries int
+
+	// MaxRateLimitWait is the maximum total delay before the retries of a
+	// rate-limited request. The delay before each retry is the response's
+	// Retry-After, or an exponential backoff from InitialBackoff if absent. If
+	// the next delay would exceed the remaining wait, the 429 response is
+	// returned.
+	// Optional. Defaults to 1 minute.
+	MaxRateLimitWait time.Duration
+}
+
+// retryPolicy returns the retry policy of the request, if any, or the
+// Datasource's.
+func (d *Datasource) retryPolicy(request *Request) *RetryPolicy {
+	if request.RetryPolicy != nil {
+		return request.RetryPolicy
+	}
+
+	return &d.RetryPolicy
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(d *Datasource) {
+		d.RetryPolicy = policy
+	}
+}
+
+// retryable returns whether the request that produced the response should be
+// retried, i.e. if the datasource failed with a transient server error or one
+// of the RetryableErrorCodes.
+func (p *RetryPolicy) retryable(response *Response, body []byte) bool {
+	if response.StatusCode == http.StatusOK {
+		return false
+	}
+
+	if slices.Contains(transientStatusCodes, response.StatusCode) {
+		return true
+	}
+
+	if len(p.RetryableErrorCodes) == 0 {
+		return false
+	}
+
+	errorBody, ok := parseErrorBody(body)
+
+	return ok && slices.Contains(p.RetryableErrorCodes, errorBody.Error.Code)
+}
+
+// retryDelay returns the delay before the given retry attempt of the request
+// that produced the response, starting from 0: the backoff, or the response's
+// Retry-After if longer.
+func (p *RetryPolicy) retryDelay(response *Response, attempt int) time.Duration {
+	delay := p.backoff(attempt)
+
+	if response == nil {
+		return delay
+	}
+
+	if err := web.HTTPError(response.StatusCode, response.RetryAfterHeader); err != nil && err.RetryAfter != nil {
+		return max(delay, *err.RetryAfter)
+	}
+
+	return delay
+}
+
+// idempotent returns whether the request can be repeated without additional
+// side effects, i.e. whether it is a read or carries an idempotency key.
+func idempotent(method string, request *Request) bool {
+	return method == http.MethodGet || method == http.MethodHead || request.IdempotencyKey != ""
+}
+
+// backoff returns the delay before the given retry attempt, starting from 0.
+// The delay is randomized between half and all of the exponential backoff, so
+// that concurrent clients don't retry in lockstep.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultInitialBackoff
+	}
+
+	// The shift is bounded to avoid overflows.
+	backoff <<= min(attempt, 30)
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// maxRateLimitRetries returns the maximum number of retries of a rate-limited
+// request.
+func (p *RetryPolicy) maxRateLimitRetries() int {
+	if p.MaxRateLimitRetries == 0 {
+		return defaultMaxRateLimitRetries
+	}
+
+	return p.MaxRateLimitRetries
+}
+
+// maxRateLimitWait returns the maximum total delay before the retries of a
+// rate-limited request.
+func (p *RetryPolicy) maxRateLimitWait() time.Duration {
+	if p.MaxRateLimitWait <= 0 {
+		return defaultMaxRateLimitWait
+	}
+
+	return p.MaxRateLimitWait
+}
+
+// rateLimitDelay returns the delay before the given retry of a rate-limited
+// request, starting from 0.
+func (p *RetryPolicy) rateLimitDelay(response *Response, attempt int) time.Duration {
+	// Retry-After is either a number of seconds or an HTTP date.
+	if err := web.HTTPError(response.StatusCode, response.RetryAfterHeader); err != nil && err.RetryAfter != nil {
+		return max(*err.RetryAfter, 0)
+	}
+
+	return p.backoff(attempt)
+}
+
+// wait waits for the given duration.
+// Returns the context's error if it is done before the duration elapses, and
+// returns errWaitPastDeadline immediately if its deadline is before the end of
+// the duration, since the context is not done yet.
+func wait(ctx context.Context, duration time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < duration {
+		return errWaitPastDeadline
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rateLimitedHandler returns a handler responding with a 429 and the
+// Retry-After header to the first rateLimited requests, then with a page of
+// users, and counting the requests.
+func rateLimitedHandler(rateLimited int32, retryAfter string, requests *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) <= rateLimited {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.Write([]byte(`{"users":[{"id":"U1"}],"more":false,"limit":100,"offset":0}`))
+	}
+}
+
+func TestGetPageRetriesRateLimitedAfterRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, rateLimitedHandler(1, "1", &requests))
+
+	start := time.Now()
+
+	response, err := NewClient(5).GetPage(context.Background(), newTestRequest(server, Users))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected the retry to wait for the Retry-After delay of 1s, waited %s", elapsed)
+	}
+
+	AssertDeepEqual(t, http.StatusOK, response.StatusCode)
+	AssertDeepEqual(t, []map[string]any{{"id": "U1"}}, response.Objects)
+	AssertDeepEqual(t, 1, response.Retries)
+	AssertDeepEqual(t, int32(2), requests.Load())
+}
+
+func TestGetPageRateLimitBudget(t *testing.T) {
+	tests := map[string]struct {
+		retryAfter   string
+		policy       RetryPolicy
+		wantRequests int32
+	}{
+		"retries_exhausted": {
+			retryAfter:   "0",
+			policy:       RetryPolicy{MaxRateLimitRetries: 2},
+			wantRequests: 3,
+		},
+		"wait_exceeded": {
+			retryAfter:   "120",
+			policy:       RetryPolicy{MaxRateLimitWait: time.Minute},
+			wantRequests: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := newTestServer(t, rateLimitedHandler(100, tt.retryAfter, &requests))
+
+			// The last rate-limited response is returned rather than waiting.
+			response, err := NewClient(5, WithRetryPolicy(tt.policy)).GetPage(context.Background(), newTestRequest(server, Users))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, http.StatusTooManyRequests, response.StatusCode)
+			AssertDeepEqual(t, tt.retryAfter, response.RetryAfterHeader)
+			AssertDeepEqual(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+func TestGetPageRateLimitCanceled(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, rateLimitedHandler(100, "30", &requests))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	response, err := NewClient(5).GetPage(ctx, newTestRequest(server, Users))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the retry to stop at the context's deadline, waited %s", elapsed)
+	}
+
+	AssertDeepEqual(t, http.StatusTooManyRequests, response.StatusCode)
+	AssertDeepEqual(t, int32(1), requests.Load())
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+const (
+	// defaultMaxTeamDepth is the number of levels of child teams below the root
+	// team returned by GetTeamSubtree when no max depth is specified.
+	defaultMaxTeamDepth = 5
+
+	// defaultMaxSubtreeTeams is the maximum number of teams returned by
+	// GetTeamSubtree when no maximum is specified.
+	defaultMaxSubtreeTeams = 1000
+)
+
+// GetTeamSubtree returns the given root team followed by its descendant teams,
+// breadth-first, down to maxDepth levels below the root and at most maxTeams
+// teams in total. Non-positive values use the defaults.
+//
+// The teams endpoint has no children filter, so the hierarchy is built from
+// the `parent` reference of all the teams of the account. Teams reachable more
+// than once, e.g. due to a cycle in the parent references, are only returned
+// once. When the subtree has more than maxTeams teams, the deepest teams are
+// left out.
+func (d *Datasource) GetTeamSubtree(
+	ctx context.Context, request *Request, rootTeamID string, maxDepth, maxTeams int,
+) ([]map[string]any, *framework.Error) {
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxTeamDepth
+	}
+
+	if maxTeams <= 0 {
+		maxTeams = defaultMaxSubtreeTeams
+	}
+
+	teamsRequest := *request
+	teamsRequest.EntityExternalID = Teams
+	teamsRequest.Attributes = nil
+	teamsRequest.ParentID = ""
+	teamsRequest.Cursor = ""
+
+	teams, err := d.GetAllPages(ctx, &teamsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]any
+
+	children := make(map[string][]map[string]any)
+
+	for _, team := range teams.Objects {
+		if id, _ := team["id"].(string); id == rootTeamID {
+			root = team
+		}
+
+		parent, _ := team["parent"].(map[string]any)
+		if parentID, _ := parent["id"].(string); parentID != "" {
+			children[parentID] = append(children[parentID], team)
+		}
+	}
+
+	if root == nil {
+		return nil, notFoundError(Teams, rootTeamID)
+	}
+
+	subtree := []map[string]any{root}
+	visited := map[string]struct{}{rootTeamID: {}}
+	level := []string{rootTeamID}
+
+	for depth := 1; depth <= maxDepth && len(level) > 0; depth++ {
+		var next []string
+
+		for _, parentID := range level {
+			for _, child := range children[parentID] {
+				id, _ := child["id"].(string)
+				if _, found := visited[id]; found {
+					continue
+				}
+
+				if len(subtree) == maxTeams {
+					return subtree, nil
+				}
+
+				visited[id] = struct{}{}
+				subtree = append(subtree, child)
+				next = append(next, id)
+			}
+		}
+
+		level = next
+	}
+
+	return subtree, nil
+}
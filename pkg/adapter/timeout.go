@@ -0,0 +1,107 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveTimeoutThreshold is the number of consecutive timed out attempts
+	// after which the adaptive attempt timeout is increased.
+	adaptiveTimeoutThreshold = 2
+)
+
+// adaptiveTimeout is an attempt timeout that grows when consecutive attempts
+// time out and shrinks back when attempts succeed, so that legitimately slow
+// pages eventually succeed without slowing down the detection of unresponsive
+// requests for fast ones.
+type adaptiveTimeout struct {
+	mu sync.Mutex
+
+	// maxTimeout is the maximum attempt timeout. If zero, the timeout is not
+	// adapted.
+	maxTimeout time.Duration
+
+	// extra is the duration added to the request's attempt timeout.
+	extra time.Duration
+
+	// timeouts is the number of consecutive timed out attempts.
+	timeouts int
+}
+
+// WithAdaptiveTimeout doubles the attempt timeout of requests, up to
+// maxTimeout, after consecutive attempts timed out, and halves it back towards
+// the request's AttemptTimeout after each successful attempt.
+// The timeout is shared by all the requests of the Datasource, and attempts
+// remain bounded by the request's OperationTimeout and the context's deadline,
+// but not by the Timeout of the Client.
+func WithAdaptiveTimeout(maxTimeout time.Duration) ClientOption {
+	return func(d *Datasource) {
+		d.timeout.maxTimeout = maxTimeout
+	}
+}
+
+// get returns the attempt timeout given the request's base timeout.
+func (t *adaptiveTimeout) get(base time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.maxTimeout <= base {
+		return base
+	}
+
+	return min(base+t.extra, t.maxTimeout)
+}
+
+// observe records whether an attempt with the given attempt timeout timed out.
+func (t *adaptiveTimeout) observe(base, timeout time.Duration, timedOut bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.maxTimeout <= base {
+		return
+	}
+
+	if !timedOut {
+		t.timeouts = 0
+		t.extra /= 2
+
+		return
+	}
+
+	t.timeouts++
+
+	if t.timeouts >= adaptiveTimeoutThreshold {
+		t.timeouts = 0
+		t.extra = min(2*timeout, t.maxTimeout) - base
+	}
+}
+
+// httpClient returns the Client sending the attempts of requests. Attempts are
+// bounded by their context, so the Client's Timeout, which only defaults the
+// attempt timeout, is cleared so as not to cut short adapted attempt timeouts.
+func (d *Datasource) httpClient() *http.Client {
+	if d.Client.Timeout == 0 {
+		return d.Client
+	}
+
+	client := *d.Client
+	client.Timeout = 0
+
+	return &client
+}
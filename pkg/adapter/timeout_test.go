@@ -0,0 +1,194 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// slowHandler returns a handler responding with the status code after the
+// delay, or when the request is canceled, and counting the requests.
+func slowHandler(delay time.Duration, statusCode int, requests *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+
+		w.WriteHeader(statusCode)
+		w.Write([]byte(`{"users":[],"more":false,"limit":100,"offset":0}`))
+	}
+}
+
+func TestGetPageAttemptTimeout(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, slowHandler(time.Minute, http.StatusOK, &requests))
+
+	// Each attempt, including the retries, is bounded by the attempt timeout.
+	request := newTestRequest(server, Users)
+	request.AttemptTimeout = 50 * time.Millisecond
+	request.RetryPolicy = &RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	start := time.Now()
+
+	_, err := NewClient(5).GetPage(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected a timeout error")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the attempts to time out after 50ms each, took %s", elapsed)
+	}
+
+	AssertDeepEqual(t, timeoutMessage, err.Message)
+	AssertDeepEqual(t, int32(3), requests.Load())
+}
+
+func TestGetPageOperationTimeout(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, slowHandler(50*time.Millisecond, http.StatusServiceUnavailable, &requests))
+
+	// The retries share the operation timeout, which is shorter than the
+	// attempts and delays of all the retries.
+	request := newTestRequest(server, Users)
+	request.AttemptTimeout = time.Second
+	request.OperationTimeout = 300 * time.Millisecond
+	request.RetryPolicy = &RetryPolicy{MaxRetries: 100, InitialBackoff: time.Millisecond}
+
+	start := time.Now()
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the retries to stop after the operation timeout of 300ms, took %s", elapsed)
+	}
+
+	if err == nil && response.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the last unavailable response or an error, got status code %d", response.StatusCode)
+	}
+
+	if n := requests.Load(); n < 2 || n >= 100 {
+		t.Errorf("Expected the request to be retried within the operation timeout, got %d requests", n)
+	}
+}
+
+func TestGetPageSlowServer(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, slowHandler(time.Minute, http.StatusOK, &requests))
+
+	request := newTestRequest(server, Users)
+	request.AttemptTimeout = 50 * time.Millisecond
+
+	_, err := NewClient(5).GetPage(context.Background(), request)
+
+	AssertDeepEqual(t, &framework.Error{
+		Message: timeoutMessage,
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+	}, err)
+	AssertDeepEqual(t, int32(1), requests.Load())
+}
+
+func TestGetPageAdaptiveTimeoutBodyRead(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The headers and the start of the body are sent before the timeout.
+		w.Write([]byte(`{"users":[`))
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+	})
+
+	request := newTestRequest(server, Users)
+	request.AttemptTimeout = 50 * time.Millisecond
+
+	datasource := NewClient(5, WithAdaptiveTimeout(time.Second)).(*Datasource)
+
+	for i := 0; i < adaptiveTimeoutThreshold; i++ {
+		if _, err := datasource.GetPage(context.Background(), request); err == nil {
+			t.Fatal("Expected an error for the timed out body")
+		}
+	}
+
+	// The attempts timed out while reading the body, so the timeout is
+	// increased.
+	AssertDeepEqual(t, 100*time.Millisecond, datasource.timeout.get(request.AttemptTimeout))
+}
+
+func TestGetPageDefaultAttemptTimeout(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, slowHandler(150*time.Millisecond, http.StatusOK, &requests))
+
+	// The attempts default to the timeout of the Client, and the adapted
+	// timeout is not capped by it.
+	request := newTestRequest(server, Users)
+	request.RetryPolicy = &RetryPolicy{MaxRetries: adaptiveTimeoutThreshold, InitialBackoff: time.Millisecond}
+
+	datasource := NewClient(5, WithAdaptiveTimeout(time.Second)).(*Datasource)
+	datasource.Client.Timeout = 100 * time.Millisecond
+
+	response, err := datasource.GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed with the adapted timeout, got %v", err)
+	}
+
+	AssertDeepEqual(t, http.StatusOK, response.StatusCode)
+	AssertDeepEqual(t, int32(adaptiveTimeoutThreshold+1), requests.Load())
+}
+
+func TestGetPageAttemptTimeoutContextDeadline(t *testing.T) {
+	var requests atomic.Int32
+
+	server := newTestServer(t, slowHandler(time.Minute, http.StatusOK, &requests))
+
+	request := newTestRequest(server, Users)
+	request.AttemptTimeout = time.Second
+
+	datasource := NewClient(5, WithAdaptiveTimeout(10*time.Second)).(*Datasource)
+
+	for i := 0; i < adaptiveTimeoutThreshold; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		start := time.Now()
+
+		_, err := datasource.GetPage(ctx, request)
+
+		cancel()
+
+		if err == nil {
+			t.Fatal("Expected a timeout error")
+		}
+
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected the attempt to be bounded by the context's deadline, took %s", elapsed)
+		}
+	}
+
+	// The attempts were cut short by the context's deadline, not by the
+	// attempt timeout, so the timeout is not increased.
+	AssertDeepEqual(t, request.AttemptTimeout, datasource.timeout.get(request.AttemptTimeout))
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// railsTimeZones maps the display names of time zones used by parts of the
+// PagerDuty API, inherited from Ruby on Rails (e.g. "Eastern Time (US &
+// Canada)"), to their IANA name.
+var railsTimeZones = map[string]string{
+	"International Date Line West": "Etc/GMT+12",
+	"Midway Island":                "Pacific/Midway",
+	"American Samoa":               "Pacific/Pago_Pago",
+	"Hawaii":                       "Pacific/Honolulu",
+	"Alaska":                       "America/Juneau",
+	"Pacific Time (US & Canada)":   "America/Los_Angeles",
+	"Tijuana":                      "America/Tijuana",
+	"Mountain Time (US & Canada)":  "America/Denver",
+	"Arizona":                      "America/Phoenix",
+	"Central Time (US & Canada)":   "America/Chicago",
+	"Mexico City":                  "America/Mexico_City",
+	"Saskatchewan":                 "America/Regina",
+	"Eastern Time (US & Canada)":   "America/New_York",
+	"Indiana (East)":               "America/Indiana/Indianapolis",
+	"Bogota":                       "America/Bogota",
+	"Lima":                         "America/Lima",
+	"Atlantic Time (Canada)":       "America/Halifax",
+	"Caracas":                      "America/Caracas",
+	"Santiago":                     "America/Santiago",
+	"Newfoundland":                 "America/St_Johns",
+	"Brasilia":                     "America/Sao_Paulo",
+	"Buenos Aires":                 "America/Argentina/Buenos_Aires",
+	"Greenland":                    "America/Godthab",
+	"Mid-Atlantic":                 "Atlantic/South_Georgia",
+	"Azores":                       "Atlantic/Azores",
+	"Cape Verde Is.":               "Atlantic/Cape_Verde",
+	"UTC":                          "Etc/UTC",
+	"London":                       "Europe/London",
+	"Edinburgh":                    "Europe/London",
+	"Dublin":                       "Europe/Dublin",
+	"Lisbon":                       "Europe/Lisbon",
+	"Casablanca":                   "Africa/Casablanca",
+	"Monrovia":                     "Africa/Monrovia",
+	"Amsterdam":                    "Europe/Amsterdam",
+	"Berlin":                       "Europe/Berlin",
+	"Bern":                         "Europe/Zurich",
+	"Brussels":                     "Europe/Brussels",
+	"Madrid":                       "Europe/Madrid",
+	"Paris":                        "Europe/Paris",
+	"Prague":                       "Europe/Prague",
+	"Rome":                         "Europe/Rome",
+	"Stockholm":                    "Europe/Stockholm",
+	"Vienna":                       "Europe/Vienna",
+	"Warsaw":                       "Europe/Warsaw",
+	"West Central Africa":          "Africa/Algiers",
+	"Athens":                       "Europe/Athens",
+	"Bucharest":                    "Europe/Bucharest",
+	"Cairo":                        "Africa/Cairo",
+	"Helsinki":                     "Europe/Helsinki",
+	"Jerusalem":                    "Asia/Jerusalem",
+	"Kyiv":                         "Europe/Kiev",
+	"Pretoria":                     "Africa/Johannesburg",
+	"Istanbul":                     "Europe/Istanbul",
+	"Moscow":                       "Europe/Moscow",
+	"Nairobi":                      "Africa/Nairobi",
+	"Riyadh":                       "Asia/Riyadh",
+	"Tehran":                       "Asia/Tehran",
+	"Abu Dhabi":                    "Asia/Muscat",
+	"Dubai":                        "Asia/Dubai",
+	"Kabul":                        "Asia/Kabul",
+	"Karachi":                      "Asia/Karachi",
+	"Chennai":                      "Asia/Kolkata",
+	"Kolkata":                      "Asia/Kolkata",
+	"Mumbai":                       "Asia/Kolkata",
+	"New Delhi":                    "Asia/Kolkata",
+	"Kathmandu":                    "Asia/Kathmandu",
+	"Dhaka":                        "Asia/Dhaka",
+	"Bangkok":                      "Asia/Bangkok",
+	"Jakarta":                      "Asia/Jakarta",
+	"Beijing":                      "Asia/Shanghai",
+	"Hong Kong":                    "Asia/Hong_Kong",
+	"Singapore":                    "Asia/Singapore",
+	"Taipei":                       "Asia/Taipei",
+	"Perth":                        "Australia/Perth",
+	"Seoul":                        "Asia/Seoul",
+	"Tokyo":                        "Asia/Tokyo",
+	"Osaka":                        "Asia/Tokyo",
+	"Adelaide":                     "Australia/Adelaide",
+	"Darwin":                       "Australia/Darwin",
+	"Brisbane":                     "Australia/Brisbane",
+	"Melbourne":                    "Australia/Melbourne",
+	"Sydney":                       "Australia/Sydney",
+	"Canberra":                     "Australia/Melbourne",
+	"Hobart":                       "Australia/Hobart",
+	"Auckland":                     "Pacific/Auckland",
+	"Wellington":                   "Pacific/Auckland",
+	"Fiji":                         "Pacific/Fiji",
+}
+
+// WithTimeZoneNormalization replaces the time zone display names found at the
+// given attribute paths (e.g. `support_hours.time_zone`) with their IANA name,
+// e.g. "Eastern Time (US & Canada)" with "America/New_York".
+// Missing or null attributes (e.g. services without support hours), IANA
+// names and unrecognized names are left unchanged.
+func WithTimeZoneNormalization(paths ...string) ParseOption {
+	return eachObject(func(object map[string]any) *framework.Error {
+		for _, path := range paths {
+			parent, key := object, path
+
+			if lastDot := strings.LastIndex(path, "."); lastDot >= 0 {
+				value, _ := attributeValue(object, path[:lastDot])
+				parent, _ = value.(map[string]any)
+				key = path[lastDot+1:]
+			}
+
+			name, ok := parent[key].(string)
+			if !ok {
+				continue
+			}
+
+			if ianaName, found := railsTimeZones[name]; found {
+				parent[key] = ianaName
+			}
+		}
+
+		return nil
+	})
+}
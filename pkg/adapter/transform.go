@@ -0,0 +1,480 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+const (
+	// HashAttribute is the key under which the content hash of each object is
+	// added when requested.
+	HashAttribute = "_hash"
+
+	// EntityAttribute is the key under which the entity external ID of each
+	// object is added when requested.
+	// PagerDuty attributes never start with an underscore, so this doesn't
+	// collide with datasource attributes.
+	EntityAttribute = "_entity"
+
+	// FetchedAtAttribute is the key under which the time each object was
+	// fetched from the datasource is added when requested.
+	FetchedAtAttribute = "_fetched_at"
+
+	// HighWaterMarkAttribute is the key under which the Adapter adds the
+	// high-water mark of incremental syncs to the last object of each page,
+	// i.e. the latest Response.HighWaterMark of the page and the previous
+	// pages. Its value on the last object of a sync is the Config.Since of the
+	// next incremental sync.
+	HighWaterMarkAttribute = "_high_water_mark"
+
+	// DuplicateAttribute is the key set to true on the objects whose unique ID
+	// was already used by a previous object of the page, with
+	// DuplicateIDsAnnotate.
+	DuplicateAttribute = "_duplicate"
+
+	// CompositeIDSeparator separates the components of composite unique IDs.
+	CompositeIDSeparator = ":"
+
+	// defaultFlattenSeparator is the separator used to flatten the objects of
+	// entities that don't define their own.
+	defaultFlattenSeparator = "."
+)
+
+// DuplicateIDHandling is how objects whose unique ID was already used by a
+// previous object of the same page are handled.
+type DuplicateIDHandling int
+
+const (
+	// DuplicateIDsDefault is DuplicateIDsError if Request.StrictPaging is set,
+	// and DuplicateIDsPassThrough otherwise. Equivalent to
+	// DuplicateIDsPassThrough outside of GetPage.
+	DuplicateIDsDefault DuplicateIDHandling = iota
+
+	// DuplicateIDsPassThrough returns duplicates as is.
+	DuplicateIDsPassThrough
+
+	// DuplicateIDsError returns an error for the page.
+	DuplicateIDsError
+
+	// DuplicateIDsDrop removes the duplicates, keeping the first object with
+	// each unique ID.
+	DuplicateIDsDrop
+
+	// DuplicateIDsAnnotate sets DuplicateAttribute on the duplicates.
+	DuplicateIDsAnnotate
+)
+
+var (
+	// defaultHashExcludedAttrs is the list of attributes excluded from the
+	// object hash for entities that don't define their own.
+	defaultHashExcludedAttrs = []string{"self", "html_url", "summary"}
+
+	// defaultNoiseAttrs is the list of attributes removed from objects when
+	// Request.DropNoiseAttributes is set without Request.NoiseAttributes, for
+	// entities that don't define their own.
+	defaultNoiseAttrs = []string{"html_url", "self", "summary", "type"}
+)
+
+// ParseOption is a transformation applied by ParseResponse to the parsed page
+// of objects. It returns the transformed page, which may contain fewer objects.
+type ParseOption func(objects []map[string]any) ([]map[string]any, *framework.Error)
+
+// eachObject returns a ParseOption that applies the transformation to each
+// object of the page in place.
+func eachObject(transform func(object map[string]any) *framework.Error) ParseOption {
+	return func(objects []map[string]any) ([]map[string]any, *framework.Error) {
+		for _, object := range objects {
+			if err := transform(object); err != nil {
+				return nil, err
+			}
+		}
+
+		return objects, nil
+	}
+}
+
+// WithObjectHash adds a hex-encoded SHA-256 hash of each object under the
+// HashAttribute key. The excluded attributes don't contribute to the hash.
+func WithObjectHash(excluded ...string) ParseOption {
+	return eachObject(func(object map[string]any) *framework.Error {
+		hashed := make(map[string]any, len(object))
+
+		for key, value := range object {
+			hashed[key] = value
+		}
+
+		for _, key := range excluded {
+			delete(hashed, key)
+		}
+
+		delete(hashed, HashAttribute)
+
+		// Map keys are marshaled in sorted order, so the encoding is stable.
+		encoded, err := json.Marshal(hashed)
+		if err != nil {
+			return &framework.Error{
+				Message: fmt.Sprintf("Failed to hash datasource object: %v.", err),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+			}
+		}
+
+		sum := sha256.Sum256(encoded)
+		object[HashAttribute] = hex.EncodeToString(sum[:])
+
+		return nil
+	})
+}
+
+// WithEntityTag adds the entity external ID under the EntityAttribute key of
+// each object, so that objects from multiple entities remain identifiable once
+// aggregated.
+func WithEntityTag(entityExternalID string) ParseOption {
+	return eachObject(func(object map[string]any) *framework.Error {
+		object[EntityAttribute] = entityExternalID
+
+		return nil
+	})
+}
+
+// WithFlattening replaces the nested objects and lists of each object with
+// top-level attributes whose keys are the paths to the nested values, joined
+// with the separator, e.g. `parent.id` or `escalation_rules.0.id`.
+// Values nested deeper than maxDepth levels are kept as-is. If maxDepth is 0,
+// objects are flattened completely.
+func WithFlattening(separator string, maxDepth int) ParseOption {
+	return eachObject(func(object map[string]any) *framework.Error {
+		flattened := make(map[string]any, len(object))
+
+		for key, value := range object {
+			flatten(flattened, key, value, separator, 1, maxDepth)
+		}
+
+		clear(object)
+
+		for key, value := range flattened {
+			object[key] = value
+		}
+
+		return nil
+	})
+}
+
+// flattening returns the separator and maximum depth used to flatten the
+// objects of the entity, the request's overriding the entity's, or an empty
+// separator if the objects are not flattened.
+func flattening(entity Entity, request *Request) (string, int) {
+	if !request.Flatten && request.FlattenSeparator == "" {
+		return "", 0
+	}
+
+	separator := request.FlattenSeparator
+	if separator == "" {
+		separator = entity.flattenSeparator
+	}
+
+	if separator == "" {
+		separator = defaultFlattenSeparator
+	}
+
+	maxDepth := request.FlattenMaxDepth
+	if maxDepth == 0 {
+		maxDepth = entity.flattenMaxDepth
+	}
+
+	return separator, maxDepth
+}
+
+// flatten adds the value to the flattened object under the key, recursively
+// flattening nested objects and lists until maxDepth is reached.
+func flatten(flattened map[string]any, key string, value any, separator string, depth, maxDepth int) {
+	if maxDepth > 0 && depth > maxDepth {
+		flattened[key] = value
+
+		return
+	}
+
+	switch nested := value.(type) {
+	case map[string]any:
+		if len(nested) == 0 {
+			flattened[key] = value
+
+			return
+		}
+
+		for nestedKey, nestedValue := range nested {
+			flatten(flattened, key+separator+nestedKey, nestedValue, separator, depth+1, maxDepth)
+		}
+	case []any:
+		if len(nested) == 0 {
+			flattened[key] = value
+
+			return
+		}
+
+		for i, nestedValue := range nested {
+			flatten(flattened, key+separator+strconv.Itoa(i), nestedValue, separator, depth+1, maxDepth)
+		}
+	default:
+		flattened[key] = value
+	}
+}
+
+// WithoutAttributes removes the given attributes from each object.
+func WithoutAttributes(attributes ...string) ParseOption {
+	return eachObject(func(object map[string]any) *framework.Error {
+		for _, attribute := range attributes {
+			delete(object, attribute)
+		}
+
+		return nil
+	})
+}
+
+// WithAttributeProjection removes the attributes of each object that are not
+// referenced by the given attributes, which may be JSONPath expressions, e.g.
+// `$.escalation_policy.id` keeps the whole `escalation_policy` attribute.
+func WithAttributeProjection(attributes ...string) ParseOption {
+	kept := make(map[string]struct{}, len(attributes))
+
+	for _, attribute := range attributes {
+		kept[topLevelAttribute(attribute)] = struct{}{}
+	}
+
+	return eachObject(func(object map[string]any) *framework.Error {
+		for key := range object {
+			if _, found := kept[key]; !found {
+				delete(object, key)
+			}
+		}
+
+		return nil
+	})
+}
+
+// topLevelAttribute returns the name of the top-level attribute referenced by
+// the attribute, e.g. `user` for `$.user.id` or `teams` for `$.teams[0]`.
+func topLevelAttribute(attribute string) string {
+	attribute = strings.TrimPrefix(attribute, "$.")
+
+	if i := strings.IndexAny(attribute, ".["); i >= 0 {
+		return attribute[:i]
+	}
+
+	return attribute
+}
+
+// WithCompositeID sets the attribute of each object to a composite ID made of
+// the values of the component attributes, joined with CompositeIDSeparator.
+// Components may be paths to nested attributes, e.g. `user.id`. The prefixes
+// (e.g. a parent object ID) are prepended to the components.
+// Returns an error if a component is missing from an object.
+func WithCompositeID(attribute string, components []string, prefixes ...string) ParseOption {
+	return func(objects []map[string]any) ([]map[string]any, *framework.Error) {
+		for i, object := range objects {
+			parts := append([]string{}, prefixes...)
+
+			for _, component := range components {
+				value, found := attributeValue(object, component)
+				if !found || value == nil || value == "" {
+					return nil, &framework.Error{
+						Message: fmt.Sprintf("Datasource object at index %d is missing unique ID component %s.", i, component),
+						Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+					}
+				}
+
+				parts = append(parts, fmt.Sprint(value))
+			}
+
+			object[attribute] = strings.Join(parts, CompositeIDSeparator)
+		}
+
+		return objects, nil
+	}
+}
+
+// attributeValue returns the value of the attribute at the given dot-separated
+// path in the object, e.g. `user.id`.
+func attributeValue(object map[string]any, path string) (any, bool) {
+	var value any = object
+
+	for _, key := range strings.Split(path, ".") {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if value, ok = nested[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// WithEnumNormalization replaces the variants of enum values with their
+// canonical value, e.g. legacy role names with their current name. The aliases
+// are keyed by attribute, then by variant. Values without an alias, and
+// non-string values, are left unchanged.
+func WithEnumNormalization(aliases map[string]map[string]string) ParseOption {
+	return eachObject(func(object map[string]any) *framework.Error {
+		for attribute, variants := range aliases {
+			value, ok := object[attribute].(string)
+			if !ok {
+				continue
+			}
+
+			if canonical, found := variants[value]; found {
+				object[attribute] = canonical
+			}
+		}
+
+		return nil
+	})
+}
+
+// enumAliases returns the enum aliases applied to the objects of the entity,
+// the request's aliases of an attribute overriding the entity's, or nil if enum
+// values are not normalized.
+func enumAliases(entity Entity, request *Request) map[string]map[string]string {
+	if !request.NormalizeEnums && len(request.EnumAliases) == 0 {
+		return nil
+	}
+
+	aliases := make(map[string]map[string]string, len(entity.enumAliases)+len(request.EnumAliases))
+
+	for attribute, variants := range entity.enumAliases {
+		aliases[attribute] = variants
+	}
+
+	for attribute, variants := range request.EnumAliases {
+		aliases[attribute] = variants
+	}
+
+	return aliases
+}
+
+// WithDatetimeNormalization reformats the values of the given datetime
+// attributes as RFC3339 in UTC, e.g. "2024-01-02T03:04:05.000-08:00" as
+// "2024-01-02T11:04:05Z". Fractional seconds are kept without trailing zeros,
+// e.g. "2024-01-02T11:04:05.12Z". Attributes missing from an object or null
+// are skipped.
+// Returns an error if a value is not an RFC3339 datetime.
+func WithDatetimeNormalization(attributes ...string) ParseOption {
+	return func(objects []map[string]any) ([]map[string]any, *framework.Error) {
+		for i, object := range objects {
+			for _, attribute := range attributes {
+				value, found := object[attribute]
+				if !found || value == nil {
+					continue
+				}
+
+				raw, _ := value.(string)
+
+				datetime, err := time.Parse(time.RFC3339Nano, raw)
+				if err != nil {
+					return nil, &framework.Error{
+						Message: fmt.Sprintf("Datasource object at index %d has an invalid datetime for attribute %s: %v.", i, attribute, value),
+						Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+					}
+				}
+
+				object[attribute] = datetime.UTC().Format(time.RFC3339Nano)
+			}
+		}
+
+		return objects, nil
+	}
+}
+
+// WithDuplicateIDHandling handles the objects whose value for the unique ID
+// attribute was already used by a previous object of the page, e.g. because of
+// a datasource bug, so that downstream keying doesn't silently break.
+// Objects without a unique ID are never duplicates.
+func WithDuplicateIDHandling(uniqueIDAttr string, handling DuplicateIDHandling) ParseOption {
+	return func(objects []map[string]any) ([]map[string]any, *framework.Error) {
+		if handling == DuplicateIDsDefault || handling == DuplicateIDsPassThrough {
+			return objects, nil
+		}
+
+		seen := make(map[string]struct{}, len(objects))
+		deduplicated := objects[:0]
+
+		for i, object := range objects {
+			value, found := object[uniqueIDAttr]
+			if !found || value == nil {
+				deduplicated = append(deduplicated, object)
+
+				continue
+			}
+
+			id := fmt.Sprint(value)
+			if _, duplicate := seen[id]; !duplicate {
+				seen[id] = struct{}{}
+				deduplicated = append(deduplicated, object)
+
+				continue
+			}
+
+			switch handling {
+			case DuplicateIDsError:
+				return nil, &framework.Error{
+					Message: fmt.Sprintf("Datasource response object at index %d has the same %s as a previous object: %s.", i, uniqueIDAttr, id),
+					Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+				}
+			case DuplicateIDsAnnotate:
+				object[DuplicateAttribute] = true
+				deduplicated = append(deduplicated, object)
+			}
+		}
+
+		return deduplicated, nil
+	}
+}
+
+// WithFetchedAt adds the given fetch time, formatted as RFC3339 in UTC, under
+// the FetchedAtAttribute key of each object. All the objects of a page share
+// the same fetch time.
+func WithFetchedAt(fetchedAt time.Time) ParseOption {
+	timestamp := fetchedAt.UTC().Format(time.RFC3339)
+
+	return eachObject(func(object map[string]any) *framework.Error {
+		object[FetchedAtAttribute] = timestamp
+
+		return nil
+	})
+}
+
+// transformObjects applies the options to the page of objects in order.
+func transformObjects(objects []map[string]any, opts []ParseOption) ([]map[string]any, *framework.Error) {
+	for _, opt := range opts {
+		var err *framework.Error
+
+		if objects, err = opt(objects); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
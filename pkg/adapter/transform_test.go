@@ -0,0 +1,376 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestWithDatetimeNormalization(t *testing.T) {
+	objects := []map[string]any{
+		{"created_at": "2024-01-02T03:04:05.000-08:00", "resolved_at": "2024-01-02T12:04:05+01:00"},
+		{"created_at": "2024-01-02T11:04:05.120Z", "resolved_at": nil},
+		{"created_at": "2024-01-02T16:34:05.123456789+05:30"},
+	}
+
+	got, err := WithDatetimeNormalization("created_at", "resolved_at")(objects)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Missing and null attributes are left unchanged.
+	AssertDeepEqual(t, []map[string]any{
+		{"created_at": "2024-01-02T11:04:05Z", "resolved_at": "2024-01-02T11:04:05Z"},
+		{"created_at": "2024-01-02T11:04:05.12Z", "resolved_at": nil},
+		{"created_at": "2024-01-02T11:04:05.123456789Z"},
+	}, got)
+}
+
+func TestWithDatetimeNormalizationInvalid(t *testing.T) {
+	objects := []map[string]any{
+		{"created_at": "2024-01-02T11:04:05Z"},
+		{"created_at": "yesterday"},
+	}
+
+	_, err := WithDatetimeNormalization("created_at")(objects)
+
+	AssertDeepEqual(t, &framework.Error{
+		Message: "Datasource object at index 1 has an invalid datetime for attribute created_at: yesterday.",
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+	}, err)
+}
+
+func TestWithDuplicateIDHandling(t *testing.T) {
+	page := func() []map[string]any {
+		return []map[string]any{{"id": "U1", "name": "first"}, {"id": "U2"}, {"id": "U1", "name": "second"}, {"name": "no ID"}}
+	}
+
+	tests := map[string]struct {
+		handling    DuplicateIDHandling
+		wantObjects []map[string]any
+		wantErr     *framework.Error
+	}{
+		"default": {
+			handling:    DuplicateIDsDefault,
+			wantObjects: page(),
+		},
+		"pass_through": {
+			handling:    DuplicateIDsPassThrough,
+			wantObjects: page(),
+		},
+		"error": {
+			handling: DuplicateIDsError,
+			wantErr: &framework.Error{
+				Message: "Datasource response object at index 2 has the same id as a previous object: U1.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			},
+		},
+		"drop": {
+			handling:    DuplicateIDsDrop,
+			wantObjects: []map[string]any{{"id": "U1", "name": "first"}, {"id": "U2"}, {"name": "no ID"}},
+		},
+		"annotate": {
+			handling: DuplicateIDsAnnotate,
+			wantObjects: []map[string]any{
+				{"id": "U1", "name": "first"},
+				{"id": "U2"},
+				{"id": "U1", "name": "second", DuplicateAttribute: true},
+				{"name": "no ID"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			objects, err := WithDuplicateIDHandling("id", tt.handling)(page())
+
+			AssertDeepEqual(t, tt.wantErr, err)
+			AssertDeepEqual(t, tt.wantObjects, objects)
+		})
+	}
+}
+
+func TestGetPageDuplicateIDsStrictPaging(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"U1"},{"id":"U1"}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Users)
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "U1"}, {"id": "U1"}}, response.Objects)
+
+	// Duplicates are errors in strict mode.
+	request.StrictPaging = true
+
+	if _, err := NewClient(5).GetPage(context.Background(), request); err == nil {
+		t.Error("Expected an error for the duplicate ID in strict mode")
+	}
+}
+
+func TestGetPageTagEntity(t *testing.T) {
+	tests := map[string]struct {
+		tagEntity   bool
+		wantObjects []map[string]any
+	}{
+		"disabled_by_default": {
+			wantObjects: []map[string]any{{"id": "U1"}, {"id": "U2"}},
+		},
+		"enabled": {
+			tagEntity:   true,
+			wantObjects: []map[string]any{{"id": "U1", EntityAttribute: Users}, {"id": "U2", EntityAttribute: Users}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte(`{"users":[{"id":"U1"},{"id":"U2"}],"more":false,"limit":100,"offset":0}`))
+			})
+
+			request := newTestRequest(server, Users)
+			request.TagEntity = tt.tagEntity
+
+			response, err := NewClient(5).GetPage(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, tt.wantObjects, response.Objects)
+		})
+	}
+}
+
+func TestGetPageFetchedAt(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"U1"},{"id":"U2"},{"id":"U3"}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Users)
+	request.IncludeFetchedAt = true
+
+	before := time.Now().UTC().Truncate(time.Second)
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	after := time.Now().UTC()
+
+	fetchedAt, _ := response.Objects[0][FetchedAtAttribute].(string)
+
+	// All the objects of the page share the same fetch time.
+	for _, object := range response.Objects {
+		AssertDeepEqual(t, fetchedAt, object[FetchedAtAttribute])
+	}
+
+	parsed, parseErr := time.Parse(time.RFC3339, fetchedAt)
+	if parseErr != nil {
+		t.Fatalf("Unexpected fetch time %q: %v", fetchedAt, parseErr)
+	}
+
+	if parsed.Before(before) || parsed.After(after) {
+		t.Errorf("Expected the fetch time between %v and %v, got %v", before, after, parsed)
+	}
+}
+
+func TestWithFetchedAt(t *testing.T) {
+	fetchedAt := time.Date(2024, 1, 1, 14, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	objects, err := transformObjects([]map[string]any{{"id": "U1"}, {"id": "U2"}}, []ParseOption{WithFetchedAt(fetchedAt)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{
+		{"id": "U1", FetchedAtAttribute: "2024-01-01T13:00:00Z"},
+		{"id": "U2", FetchedAtAttribute: "2024-01-01T13:00:00Z"},
+	}, objects)
+}
+
+func TestFlattening(t *testing.T) {
+	entity := Entity{flattenSeparator: "_", flattenMaxDepth: 2}
+
+	tests := map[string]struct {
+		entity        Entity
+		request       *Request
+		wantSeparator string
+		wantMaxDepth  int
+	}{
+		"disabled_by_default": {
+			entity:  entity,
+			request: &Request{},
+		},
+		"entity_defaults": {
+			entity:        entity,
+			request:       &Request{Flatten: true},
+			wantSeparator: "_",
+			wantMaxDepth:  2,
+		},
+		"request_overrides": {
+			entity:        entity,
+			request:       &Request{Flatten: true, FlattenSeparator: "/", FlattenMaxDepth: 3},
+			wantSeparator: "/",
+			wantMaxDepth:  3,
+		},
+		"request_separator_enables": {
+			entity:        entity,
+			request:       &Request{FlattenSeparator: "/"},
+			wantSeparator: "/",
+			wantMaxDepth:  2,
+		},
+		"default_separator": {
+			request:       &Request{Flatten: true},
+			wantSeparator: ".",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			separator, maxDepth := flattening(tt.entity, tt.request)
+
+			AssertDeepEqual(t, tt.wantSeparator, separator)
+			AssertDeepEqual(t, tt.wantMaxDepth, maxDepth)
+		})
+	}
+}
+
+func TestGetPageFlatten(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"U1","contact_methods":[{"id":"C1"}]}],"more":false,"limit":100,"offset":0}`))
+	})
+
+	request := newTestRequest(server, Users)
+	request.Flatten = true
+
+	response, err := NewClient(5).GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "U1", "contact_methods.0.id": "C1"}}, response.Objects)
+}
+
+func TestGetPageDropNoiseAttributes(t *testing.T) {
+	object := `{"id":"P1","summary":"Summary","self":"https://api.pagerduty.com/P1","type":"object","name":"Name"}`
+
+	tests := map[string]struct {
+		entity      string
+		dropNoise   bool
+		noiseAttrs  []string
+		wantObjects []map[string]any
+	}{
+		"disabled_by_default": {
+			entity: Users,
+			wantObjects: []map[string]any{
+				{"id": "P1", "summary": "Summary", "self": "https://api.pagerduty.com/P1", "type": "object", "name": "Name"},
+			},
+		},
+		"default_noise": {
+			entity:      Users,
+			dropNoise:   true,
+			wantObjects: []map[string]any{{"id": "P1", "name": "Name"}},
+		},
+		"entity_noise": {
+			entity:      Incidents,
+			dropNoise:   true,
+			wantObjects: []map[string]any{{"id": "P1", "summary": "Summary", "name": "Name"}},
+		},
+		"request_noise": {
+			entity:      Incidents,
+			dropNoise:   true,
+			noiseAttrs:  []string{"id", "name"},
+			wantObjects: []map[string]any{{"id": "P1", "summary": "Summary", "self": "https://api.pagerduty.com/P1", "type": "object"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte(`{"` + tt.entity + `":[` + object + `],"more":false,"limit":100,"offset":0}`))
+			})
+
+			request := newTestRequest(server, tt.entity)
+			request.DropNoiseAttributes = tt.dropNoise
+			request.NoiseAttributes = tt.noiseAttrs
+
+			response, err := NewClient(5).GetPage(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			AssertDeepEqual(t, tt.wantObjects, response.Objects)
+		})
+	}
+}
+
+func TestEnumAliases(t *testing.T) {
+	entity := Entity{enumAliases: map[string]map[string]string{
+		"role":    {"limited_user": "responder"},
+		"urgency": {"HIGH": "high"},
+	}}
+
+	tests := map[string]struct {
+		entity      Entity
+		request     *Request
+		wantAliases map[string]map[string]string
+	}{
+		"disabled_by_default": {
+			entity:  entity,
+			request: &Request{},
+		},
+		"entity_aliases": {
+			entity:      entity,
+			request:     &Request{NormalizeEnums: true},
+			wantAliases: entity.enumAliases,
+		},
+		"request_overrides_attribute": {
+			entity: entity,
+			request: &Request{
+				NormalizeEnums: true,
+				EnumAliases:    map[string]map[string]string{"role": {"read_only_user": "observer"}},
+			},
+			wantAliases: map[string]map[string]string{
+				"role":    {"read_only_user": "observer"},
+				"urgency": {"HIGH": "high"},
+			},
+		},
+		"request_aliases_enable": {
+			request:     &Request{EnumAliases: map[string]map[string]string{"role": {"limited_user": "responder"}}},
+			wantAliases: map[string]map[string]string{"role": {"limited_user": "responder"}},
+		},
+		"entity_without_aliases": {
+			request:     &Request{NormalizeEnums: true},
+			wantAliases: map[string]map[string]string{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			AssertDeepEqual(t, tt.wantAliases, enumAliases(tt.entity, tt.request))
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// transportOptions configures the HTTP transport used by the Datasource.
+type transportOptions struct {
+	// resolver is the resolver used to look up datasource hosts.
+	// If nil, the system resolver is used.
+	resolver *net.Resolver
+
+	// hostOverrides maps datasource hosts to the IP addresses to connect to,
+	// bypassing DNS resolution for those hosts.
+	hostOverrides map[string]string
+
+	// proxy is the URL of the HTTP(S) proxy requests are sent through.
+	// If nil, the proxy is configured by the environment, e.g. HTTPS_PROXY.
+	proxy *url.URL
+
+	// rootCAs is the set of CAs trusted to verify the datasource
+	// certificates. If nil, the system CAs are trusted.
+	rootCAs *x509.CertPool
+
+	// certificates are the client certificates presented to the datasource or
+	// proxy.
+	certificates []tls.Certificate
+
+	// insecureSkipVerify disables the verification of the datasource
+	// certificates.
+	insecureSkipVerify bool
+
+	// replay serves fixtures instead of requesting the datasource, if set.
+	replay *replayTransport
+
+	// recordDir is the directory the responses of the datasource are recorded
+	// to as fixtures, if set.
+	recordDir string
+}
+
+// WithResolver sets the resolver used to look up datasource hosts, e.g. a
+// caching resolver or one using a specific DNS server.
+func WithResolver(resolver *net.Resolver) ClientOption {
+	return func(d *Datasource) {
+		d.transport.resolver = resolver
+	}
+}
+
+// WithHostOverrides pins datasource hosts to static IP addresses, e.g.
+// "api.pagerduty.com" to a previously resolved IP, bypassing DNS resolution.
+// TLS certificates are still verified against the original host name.
+func WithHostOverrides(overrides map[string]string) ClientOption {
+	return func(d *Datasource) {
+		d.transport.hostOverrides = overrides
+	}
+}
+
+// WithProxy sends requests through the HTTP(S) proxy at proxyURL, e.g.
+// "http://proxy.example.com:3128", overriding the proxy configured by the
+// environment.
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(d *Datasource) {
+		d.transport.proxy = proxyURL
+	}
+}
+
+// WithRootCAs sets the CAs trusted to verify the datasource certificates, e.g.
+// to trust the CA of a TLS-inspecting proxy.
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(d *Datasource) {
+		d.transport.rootCAs = pool
+	}
+}
+
+// WithClientCertificates sets the client certificates presented during TLS
+// handshakes, e.g. to authenticate with a proxy requiring mutual TLS.
+func WithClientCertificates(certificates ...tls.Certificate) ClientOption {
+	return func(d *Datasource) {
+		d.transport.certificates = certificates
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the datasource
+// certificates.
+// Discouraged: this exposes requests and tokens to interception. Prefer
+// WithRootCAs to trust a custom CA.
+func WithInsecureSkipVerify() ClientOption {
+	return func(d *Datasource) {
+		d.transport.insecureSkipVerify = true
+	}
+}
+
+// LoadCABundle returns the system CAs, along with the CAs of the PEM bundle at
+// path.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, errors.New("CA bundle contains no PEM certificates")
+	}
+
+	return pool, nil
+}
+
+// newTransport returns an HTTP transport based on http.DefaultTransport,
+// configured with the options.
+func (o *transportOptions) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  o.resolver,
+	}
+
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if ip, found := o.hostOverrides[host]; found {
+				address = net.JoinHostPort(ip, port)
+			}
+		}
+
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	if o.proxy != nil {
+		transport.Proxy = http.ProxyURL(o.proxy)
+	}
+
+	if o.rootCAs != nil || len(o.certificates) > 0 || o.insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			RootCAs:            o.rootCAs,
+			Certificates:       o.certificates,
+			InsecureSkipVerify: o.insecureSkipVerify,
+		}
+	}
+
+	return transport
+}
+
+// roundTripper returns the HTTP transport configured with the options,
+// replaying or recording fixtures if enabled.
+func (o *transportOptions) roundTripper() http.RoundTripper {
+	if o.replay != nil {
+		return o.replay
+	}
+
+	if o.recordDir != "" {
+		return &recordingTransport{next: o.newTransport(), dir: o.recordDir}
+	}
+
+	return o.newTransport()
+}
+
+// decodedBody returns a reader of the decompressed response body.
+//
+// Chunked transfer encoding and gzip content encoding negotiated by the
+// transport are decoded transparently by net/http. A gzip body the transport
+// didn't decompress (e.g. sent by a proxy although not requested) is
+// decompressed here.
+func decodedBody(res *http.Response) (io.Reader, error) {
+	if res.Uncompressed || !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return res.Body, nil
+	}
+
+	return gzip.NewReader(res.Body)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestGetPageHostOverrides(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		AssertDeepEqual(t, "pagerduty.test", host)
+
+		w.Write([]byte(`{"users": [{"id": "PUSER1"}], "more": false}`))
+	})
+
+	serverURL, _ := url.Parse(server.URL)
+
+	client := NewClient(5, WithHostOverrides(map[string]string{"pagerduty.test": serverURL.Hostname()}))
+
+	request := newTestRequest(server, Users)
+	request.BaseURL = "http://" + net.JoinHostPort("pagerduty.test", serverURL.Port())
+
+	res, err := client.GetPage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	AssertDeepEqual(t, []map[string]any{{"id": "PUSER1"}}, res.Objects)
+}
+
+func TestGetPageResolver(t *testing.T) {
+	var dialed bool
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			dialed = true
+
+			return nil, errors.New("no DNS server")
+		},
+	}
+
+	request := &Request{
+		BaseURL:           "http://pagerduty.test",
+		HTTPAuthorization: "Token token=test",
+		PageSize:          100,
+		EntityExternalID:  Users,
+	}
+
+	_, err := NewClient(5, WithResolver(resolver)).GetPage(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected an error resolving the host")
+	}
+
+	AssertDeepEqual(t, true, dialed)
+}
@@ -17,6 +17,10 @@ package adapter
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	framework "github.com/sgnl-ai/adapter-framework"
 	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
@@ -32,24 +36,51 @@ const (
 
 // ValidateGetPageRequest validates the fields of the GetPage Request.
 func (a *Adapter) ValidateGetPageRequest(ctx context.Context, request *framework.Request[Config]) *framework.Error {
-	//if err := request.Config.Validate(ctx); err != nil {
-	//	return &framework.Error{
-	//		Message: fmt.Sprintf("Provided config is invalid: %v.", err.Error()),
-	//		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
-	//	}
-	//}
+	// The config is optional, but must be valid if provided.
+	if request.Config != nil {
+		if err := request.Config.Validate(ctx); err != nil {
+			return &framework.Error{
+				Message: fmt.Sprintf("Provided config is invalid: %v.", err.Error()),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			}
+		}
+	}
+
+	if addressErr := validateAddress(request.Address); addressErr != nil {
+		return addressErr
+	}
 
 	// SCAFFOLDING:
 	// Modify this validation to match the authn mechanism(s) supported by the
 	// datasource.
-	if request.Auth == nil || request.Auth.HTTPAuthorization == "" {
-		return &framework.Error{
-			Message: "PagerDuty auth is missing required token.",
-			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+	//
+	// The token is obtained with the OAuth client credentials, if configured.
+	if !request.Config.hasClientCredentials() {
+		if request.Auth == nil || request.Auth.HTTPAuthorization == "" {
+			return &framework.Error{
+				Message: "PagerDuty auth is missing required token.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			}
+		}
+
+		authType := ""
+		if request.Config != nil {
+			authType = request.Config.AuthType
+		}
+
+		if authErr := validateAuthorization(request.Auth.HTTPAuthorization, authType); authErr != nil {
+			return authErr
 		}
 	}
 
-	if _, found := ValidEntityExternalIDs[request.Entity.ExternalId]; !found {
+	// Entities that are not supported natively may be defined by their
+	// response mapping.
+	entity, found := ValidEntityExternalIDs[request.Entity.ExternalId]
+	if !found {
+		entity, found = mappedEntity(request.Config.responseMapping(request.Entity.ExternalId))
+	}
+
+	if !found {
 		return &framework.Error{
 			Message: "Provided entity external ID is invalid.",
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
@@ -57,11 +88,12 @@ func (a *Adapter) ValidateGetPageRequest(ctx context.Context, request *framework
 	}
 
 	// Validate that at least the unique ID attribute for the requested entity
-	// is requested.
-	var uniqueIDAttributeFound bool
+	// is requested. Entities without a unique ID, e.g. on-calls, may be
+	// requested with any attributes.
+	uniqueIDAttributeFound := entity.uniqueIDAttrExternalID == ""
 
 	for _, attribute := range request.Entity.Attributes {
-		if attribute.ExternalId == ValidEntityExternalIDs[request.Entity.ExternalId].uniqueIDAttrExternalID {
+		if attribute.ExternalId == entity.uniqueIDAttrExternalID {
 			uniqueIDAttributeFound = true
 
 			break
@@ -97,11 +129,225 @@ func (a *Adapter) ValidateGetPageRequest(ctx context.Context, request *framework
 		}
 	}
 
-	if request.PageSize > MaxPageSize {
+	return validatePageSize(request.PageSize)
+}
+
+// validateRequest validates the fields of a datasource request before any
+// request is sent, so that malformed requests fail with a specific error
+// rather than a datasource error.
+func validateRequest(request *Request) *framework.Error {
+	// The base URL is replaced by those of the regions, if any.
+	if len(request.Regions) == 0 {
+		if addressErr := validateAddress(request.BaseURL); addressErr != nil {
+			return addressErr
+		}
+	}
+
+	for _, region := range request.Regions {
+		if addressErr := validateAddress(region.BaseURL); addressErr != nil {
+			return addressErr
+		}
+	}
+
+	// The token may instead be provided by a TokenProvider.
+	if request.HTTPAuthorization != "" {
+		if authErr := validateAuthorization(request.HTTPAuthorization, request.AuthType); authErr != nil {
+			return authErr
+		}
+	}
+
+	return validatePageSize(request.PageSize)
+}
+
+// validateAddress validates that the address of the datasource is an absolute
+// URL, e.g. "https://api.pagerduty.com".
+func validateAddress(address string) *framework.Error {
+	if address == "" {
+		return &framework.Error{
+			Message: "Provided datasource address is missing. Set it to the PagerDuty API URL, e.g. https://api.pagerduty.com.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+		}
+	}
+
+	// The adapter adds the scheme to addresses without one.
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+
+	if parsed, err := url.Parse(address); err != nil || parsed.Host == "" {
 		return &framework.Error{
-			Message: fmt.Sprintf("Provided page size (%d) exceeds maximum (%d).", request.PageSize, MaxPageSize),
+			Message: fmt.Sprintf("Provided datasource address is not a valid URL: %s.", address),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+		}
+	}
+
+	return nil
+}
+
+// validateAuthorization validates the format of the datasource token for the
+// auth type: API keys must be in the "Token token=..." scheme, and OAuth
+// access tokens must not be empty.
+func validateAuthorization(authorization, authType string) *framework.Error {
+	switch authType {
+	case "", AuthTypeToken:
+		// OAuth access tokens can also be sent as is in the Bearer scheme.
+		key, found := strings.CutPrefix(authorization, tokenPrefix)
+		if !strings.HasPrefix(authorization, bearerPrefix) && (!found || strings.TrimSpace(key) == "") {
+			return &framework.Error{
+				Message: fmt.Sprintf("PagerDuty auth token must be in the %q scheme, e.g. %q.", tokenPrefix+"...", tokenPrefix+"<API key>"),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			}
+		}
+	case AuthTypeOAuth:
+		if strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix)) == "" {
+			return &framework.Error{
+				Message: "PagerDuty OAuth access token is empty.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePageSize validates that the page size is within the limits of the
+// datasource.
+func validatePageSize(pageSize int64) *framework.Error {
+	switch {
+	case pageSize <= 0:
+		return &framework.Error{
+			Message: fmt.Sprintf("Provided page size (%d) must be positive.", pageSize),
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
 		}
+	case pageSize > MaxPageSize:
+		return &framework.Error{
+			Message: fmt.Sprintf("Provided page size (%d) exceeds maximum (%d).", pageSize, MaxPageSize),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	default:
+		return nil
+	}
+}
+
+// validateRequiredQuery validates that the query parameters contain all the
+// parameters required by the entity.
+func validateRequiredQuery(entity Entity, query url.Values) *framework.Error {
+	var missing []string
+
+	for _, key := range entity.requiredQuery {
+		if query.Get(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &framework.Error{
+			Message: fmt.Sprintf("Requested entity is missing required query parameters: %s.", strings.Join(missing, ", ")),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	return nil
+}
+
+// validateTimeWindow validates that the query parameters contain a valid time
+// window, i.e. RFC3339 `since` and `until` parameters with `since` before
+// `until`.
+func validateTimeWindow(query url.Values) *framework.Error {
+	since, sinceErr := time.Parse(time.RFC3339, query.Get("since"))
+	until, untilErr := time.Parse(time.RFC3339, query.Get("until"))
+
+	switch {
+	case sinceErr != nil || untilErr != nil:
+		return &framework.Error{
+			Message: "Requested entity requires valid RFC3339 since and until parameters.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	case !since.Before(until):
+		return &framework.Error{
+			Message: fmt.Sprintf("Provided since (%s) must be before until (%s).", query.Get("since"), query.Get("until")),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	default:
+		return nil
+	}
+}
+
+// checkPagingConsistency checks that the `more` flag of a page is consistent
+// with its number of objects: a last page containing exactly the requested
+// number of objects suggests objects may be missing, and a page having more
+// pages after it must not be empty.
+func checkPagingConsistency(count int, more bool, limit int64) *framework.Error {
+	switch {
+	case !more && limit > 0 && int64(count) == limit:
+		return &framework.Error{
+			Message: fmt.Sprintf("Datasource returned a full page of %d objects without more pages.", count),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	case more && count == 0:
+		return &framework.Error{
+			Message: "Datasource returned an empty page with more pages.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	default:
+		return nil
+	}
+}
+
+// validateURLLength validates that the request URL doesn't exceed the
+// Datasource's maximum URL length.
+func (d *Datasource) validateURLLength(requestURL string) *framework.Error {
+	maxLength := d.MaxURLLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxURLLength
+	}
+
+	if len(requestURL) > maxLength {
+		return &framework.Error{
+			Message: fmt.Sprintf("Request URL length (%d) exceeds maximum (%d). Reduce the number of filters.", len(requestURL), maxLength),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	return nil
+}
+
+// validateIncludes validates that the includes requested by the options are
+// supported by the entity, since PagerDuty rejects unknown includes with a
+// generic invalid parameters error.
+func validateIncludes(entity Entity, options *PageOptions) *framework.Error {
+	if options == nil {
+		return nil
+	}
+
+	for _, include := range options.Includes {
+		if !slices.Contains(entity.includes, include) {
+			return &framework.Error{
+				Message: fmt.Sprintf("Requested entity doesn't support including %s.", include),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateUniqueIDs validates that each object has a non-empty value for the
+// entity's unique ID attribute, if the entity has one.
+func validateUniqueIDs(objects []map[string]any, uniqueIDAttr string) *framework.Error {
+	if uniqueIDAttr == "" {
+		return nil
+	}
+
+	for i, object := range objects {
+		if value, found := object[uniqueIDAttr]; found && value != nil && value != "" {
+			continue
+		}
+
+		return &framework.Error{
+			Message: fmt.Sprintf("Datasource response object at index %d is missing the unique ID attribute %s.", i, uniqueIDAttr),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
 	}
 
 	return nil
@@ -0,0 +1,184 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"net/url"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+func TestValidateRequest(t *testing.T) {
+	tests := map[string]struct {
+		request *Request
+		wantErr *framework.Error
+	}{
+		"valid": {
+			request: &Request{BaseURL: "https://api.pagerduty.com", HTTPAuthorization: "Token token=test", PageSize: 100},
+		},
+		"address_without_scheme": {
+			request: &Request{BaseURL: "api.pagerduty.com", HTTPAuthorization: "Token token=test", PageSize: 100},
+		},
+		"token_provided_by_provider": {
+			request: &Request{BaseURL: "https://api.pagerduty.com", PageSize: 100},
+		},
+		"missing_address": {
+			request: &Request{HTTPAuthorization: "Token token=test", PageSize: 100},
+			wantErr: &framework.Error{
+				Message: "Provided datasource address is missing. Set it to the PagerDuty API URL, e.g. https://api.pagerduty.com.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			},
+		},
+		"invalid_address": {
+			request: &Request{BaseURL: "https://", HTTPAuthorization: "Token token=test", PageSize: 100},
+			wantErr: &framework.Error{
+				Message: "Provided datasource address is not a valid URL: https://.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			},
+		},
+		"regions_replace_address": {
+			request: &Request{
+				Regions:           []Region{{Name: "us", BaseURL: "https://api.pagerduty.com"}},
+				HTTPAuthorization: "Token token=test",
+				PageSize:          100,
+			},
+		},
+		"invalid_region_address": {
+			request: &Request{
+				BaseURL:           "https://api.pagerduty.com",
+				Regions:           []Region{{Name: "us", BaseURL: "https://api.pagerduty.com"}, {Name: "eu"}},
+				HTTPAuthorization: "Token token=test",
+				PageSize:          100,
+			},
+			wantErr: &framework.Error{
+				Message: "Provided datasource address is missing. Set it to the PagerDuty API URL, e.g. https://api.pagerduty.com.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			},
+		},
+		"invalid_authorization": {
+			request: &Request{BaseURL: "https://api.pagerduty.com", HTTPAuthorization: "test", PageSize: 100},
+			wantErr: &framework.Error{
+				Message: `PagerDuty auth token must be in the "Token token=..." scheme, e.g. "Token token=<API key>".`,
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			},
+		},
+		"zero_page_size": {
+			request: &Request{BaseURL: "https://api.pagerduty.com", HTTPAuthorization: "Token token=test"},
+			wantErr: &framework.Error{
+				Message: "Provided page size (0) must be positive.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+		"page_size_too_large": {
+			request: &Request{BaseURL: "https://api.pagerduty.com", HTTPAuthorization: "Token token=test", PageSize: MaxPageSize + 1},
+			wantErr: &framework.Error{
+				Message: "Provided page size (101) exceeds maximum (100).",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			AssertDeepEqual(t, tt.wantErr, validateRequest(tt.request))
+		})
+	}
+}
+
+func TestValidateAuthorization(t *testing.T) {
+	tokenErr := &framework.Error{
+		Message: `PagerDuty auth token must be in the "Token token=..." scheme, e.g. "Token token=<API key>".`,
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+	}
+
+	oauthErr := &framework.Error{
+		Message: "PagerDuty OAuth access token is empty.",
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+	}
+
+	tests := map[string]struct {
+		authorization string
+		authType      string
+		wantErr       *framework.Error
+	}{
+		"token":                {authorization: "Token token=test"},
+		"token_auth_type":      {authorization: "Token token=test", authType: AuthTypeToken},
+		"bearer_as_token":      {authorization: "Bearer test"},
+		"token_missing_scheme": {authorization: "test", wantErr: tokenErr},
+		"token_empty_key":      {authorization: "Token token= ", wantErr: tokenErr},
+		"oauth":                {authorization: "Bearer test", authType: AuthTypeOAuth},
+		"oauth_without_scheme": {authorization: "test", authType: AuthTypeOAuth},
+		"oauth_empty":          {authorization: "Bearer ", authType: AuthTypeOAuth, wantErr: oauthErr},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			AssertDeepEqual(t, tt.wantErr, validateAuthorization(tt.authorization, tt.authType))
+		})
+	}
+}
+
+func TestValidateTimeWindow(t *testing.T) {
+	invalidErr := &framework.Error{
+		Message: "Requested entity requires valid RFC3339 since and until parameters.",
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+	}
+
+	tests := map[string]struct {
+		query   url.Values
+		wantErr *framework.Error
+	}{
+		"valid": {
+			query: url.Values{"since": {"2024-01-01T00:00:00Z"}, "until": {"2024-01-02T00:00:00Z"}},
+		},
+		"valid_offsets": {
+			query: url.Values{"since": {"2024-01-01T00:00:00+02:00"}, "until": {"2024-01-01T00:00:00Z"}},
+		},
+		"missing_since": {
+			query:   url.Values{"until": {"2024-01-02T00:00:00Z"}},
+			wantErr: invalidErr,
+		},
+		"missing_until": {
+			query:   url.Values{"since": {"2024-01-01T00:00:00Z"}},
+			wantErr: invalidErr,
+		},
+		"invalid_since": {
+			query:   url.Values{"since": {"2024-01-01"}, "until": {"2024-01-02T00:00:00Z"}},
+			wantErr: invalidErr,
+		},
+		"since_equal_until": {
+			query: url.Values{"since": {"2024-01-01T00:00:00Z"}, "until": {"2024-01-01T00:00:00Z"}},
+			wantErr: &framework.Error{
+				Message: "Provided since (2024-01-01T00:00:00Z) must be before until (2024-01-01T00:00:00Z).",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+		"since_after_until": {
+			query: url.Values{"since": {"2024-01-02T00:00:00Z"}, "until": {"2024-01-01T00:00:00Z"}},
+			wantErr: &framework.Error{
+				Message: "Provided since (2024-01-02T00:00:00Z) must be before until (2024-01-01T00:00:00Z).",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			AssertDeepEqual(t, tt.wantErr, validateTimeWindow(tt.query))
+		})
+	}
+}